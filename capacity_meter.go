@@ -0,0 +1,237 @@
+package dynago
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Defaults for CapacityMeter.
+const (
+	DefaultMeterWindow  = time.Minute
+	DefaultMeterWindows = 60
+)
+
+/*
+CapacityUsage is the capacity used on a table or index.
+
+Table usage includes the capacity used on its indexes by the same operations.
+*/
+type CapacityUsage struct {
+	ReadUnits  float64
+	WriteUnits float64
+	Operations int // Number of operations which reported capacity
+}
+
+func (u *CapacityUsage) add(other CapacityUsage) {
+	u.ReadUnits += other.ReadUnits
+	u.WriteUnits += other.WriteUnits
+	u.Operations += other.Operations
+}
+
+// TableCapacityUsage is the capacity used on a table, with a breakdown by index.
+type TableCapacityUsage struct {
+	CapacityUsage
+	Indexes map[string]*CapacityUsage // Index name -> usage
+}
+
+// CapacitySnapshot is the capacity used between Start and End.
+type CapacitySnapshot struct {
+	Start  time.Time
+	End    time.Time
+	Tables map[string]*TableCapacityUsage // Table name -> usage
+}
+
+func (s *CapacitySnapshot) table(name string) *TableCapacityUsage {
+	if s.Tables == nil {
+		s.Tables = make(map[string]*TableCapacityUsage)
+	}
+	t := s.Tables[name]
+	if t == nil {
+		t = &TableCapacityUsage{Indexes: make(map[string]*CapacityUsage)}
+		s.Tables[name] = t
+	}
+	return t
+}
+
+func (s *CapacitySnapshot) add(table, index string, usage CapacityUsage) {
+	t := s.table(table)
+	if index == "" {
+		t.CapacityUsage.add(usage)
+		return
+	}
+	if t.Indexes[index] == nil {
+		t.Indexes[index] = &CapacityUsage{}
+	}
+	t.Indexes[index].add(usage)
+}
+
+func (s *CapacitySnapshot) merge(other *CapacitySnapshot) {
+	for name, t := range other.Tables {
+		s.add(name, "", t.CapacityUsage)
+		for index, usage := range t.Indexes {
+			s.add(name, index, *usage)
+		}
+	}
+}
+
+/*
+CapacityMeter adds up the capacity a client consumes, per table and index,
+in windows of time.
+
+ReturnConsumedCapacity is set to CapacityIndexes on all operations so that
+usage can be broken down by index.
+
+	meter := &dynago.CapacityMeter{Threshold: 100}
+	client := dynago.NewClient(executor, meter.Middleware())
+	...
+	lastHour := meter.Snapshot(time.Hour)
+	fmt.Println(lastHour.Tables["Users"].ReadUnits)
+
+A CapacityMeter is safe to share between goroutines and clients.
+*/
+type CapacityMeter struct {
+	Window  time.Duration // Length of each window; defaults to DefaultMeterWindow
+	Windows int           // Number of windows kept; defaults to DefaultMeterWindows
+
+	// If a single operation consumes more than Threshold units, OnThreshold
+	// is called, or if it's nil, a warning is logged with slog.Default().
+	// A zero Threshold disables the check.
+	Threshold   float64
+	OnThreshold func(op *Operation, capacity ConsumedCapacity)
+
+	lock    sync.Mutex
+	windows []*CapacitySnapshot // Oldest first
+
+	now func() time.Time // Overridden in tests
+}
+
+// Middleware makes a middleware which records capacity used by a client on this meter.
+func (m *CapacityMeter) Middleware() Middleware {
+	return func(next Invoker) Invoker {
+		return func(op *Operation) (interface{}, error) {
+			write, metered := capacityKinds[op.Name]
+			if !metered {
+				return next(op)
+			}
+			op.Request = withConsumedCapacity(op.Request, CapacityIndexes)
+			result, err := next(op)
+			for _, c := range resultCapacity(result) {
+				if c.TableName == "" {
+					c.TableName = op.Table
+				}
+				m.Record(c, write)
+				if m.Threshold > 0 && c.CapacityUnits > m.Threshold {
+					m.exceeded(op, c)
+				}
+			}
+			return result, err
+		}
+	}
+}
+
+// Record adds consumed capacity to the current window.
+func (m *CapacityMeter) Record(c ConsumedCapacity, write bool) {
+	usage := func(units float64) CapacityUsage {
+		if write {
+			return CapacityUsage{WriteUnits: units, Operations: 1}
+		}
+		return CapacityUsage{ReadUnits: units, Operations: 1}
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	window := m.currentWindow()
+	window.add(c.TableName, "", usage(c.CapacityUnits))
+	for _, indexes := range []map[string]*Capacity{c.GlobalSecondaryIndexes, c.LocalSecondaryIndexes} {
+		for index, capacity := range indexes {
+			if capacity != nil {
+				window.add(c.TableName, index, usage(capacity.CapacityUnits))
+			}
+		}
+	}
+}
+
+/*
+Snapshot gives the capacity used in the windows covering the last d of time,
+or all the windows kept if d is zero.
+*/
+func (m *CapacityMeter) Snapshot(d time.Duration) CapacitySnapshot {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	size, keep := m.windowSizes()
+	if retained := size * time.Duration(keep); d <= 0 || d > retained {
+		d = retained
+	}
+	now := m.currentTime()
+	snapshot := CapacitySnapshot{End: now, Tables: map[string]*TableCapacityUsage{}}
+	for _, window := range m.windows {
+		if !window.End.After(now.Add(-d)) {
+			continue
+		}
+		if snapshot.Start.IsZero() {
+			snapshot.Start = window.Start
+		}
+		snapshot.merge(window)
+	}
+	if snapshot.Start.IsZero() {
+		snapshot.Start = now
+	}
+	return snapshot
+}
+
+// Reset discards all recorded usage.
+func (m *CapacityMeter) Reset() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.windows = nil
+}
+
+// The window for the current time, starting a new one if needed.
+func (m *CapacityMeter) currentWindow() *CapacitySnapshot {
+	size, keep := m.windowSizes()
+	now := m.currentTime()
+	if n := len(m.windows); n > 0 && now.Before(m.windows[n-1].End) {
+		return m.windows[n-1]
+	}
+	start := now.Truncate(size)
+	window := &CapacitySnapshot{Start: start, End: start.Add(size)}
+	m.windows = append(m.windows, window)
+	oldest := start.Add(-size * time.Duration(keep-1))
+	for m.windows[0].Start.Before(oldest) {
+		m.windows = m.windows[1:]
+	}
+	return window
+}
+
+func (m *CapacityMeter) windowSizes() (size time.Duration, keep int) {
+	size, keep = m.Window, m.Windows
+	if size <= 0 {
+		size = DefaultMeterWindow
+	}
+	if keep <= 0 {
+		keep = DefaultMeterWindows
+	}
+	return
+}
+
+func (m *CapacityMeter) exceeded(op *Operation, c ConsumedCapacity) {
+	if m.OnThreshold != nil {
+		m.OnThreshold(op, c)
+		return
+	}
+	slog.Default().LogAttrs(context.Background(), slog.LevelWarn, "dynago: operation exceeded capacity threshold",
+		slog.String("operation", op.Name),
+		slog.String("table", c.TableName),
+		slog.String("index", op.Index),
+		slog.Float64("capacity_units", c.CapacityUnits),
+		slog.Float64("threshold", m.Threshold),
+	)
+}
+
+func (m *CapacityMeter) currentTime() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,74 @@
+package dynago
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func meterSetUp(t *testing.T) (*assert.Assertions, *CapacityMeter, *fakeClock, *MockExecutor, *Client) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 30, 0, time.UTC)}
+	meter := &CapacityMeter{now: clock.Now, Window: time.Minute, Windows: 3}
+	executor := &MockExecutor{}
+	return assert.New(t), meter, clock, executor, NewClient(executor, meter.Middleware())
+}
+
+func TestCapacityMeter(t *testing.T) {
+	assert, meter, clock, executor, client := meterSetUp(t)
+	executor.QueryResult = &QueryResult{ConsumedCapacity: &ConsumedCapacity{
+		TableName:              "Users",
+		CapacityUnits:          3,
+		Table:                  &Capacity{1},
+		GlobalSecondaryIndexes: map[string]*Capacity{"ByName": {2}},
+	}}
+	executor.UpdateItemResult = &UpdateItemResult{ConsumedCapacity: &ConsumedCapacity{CapacityUnits: 1.5}}
+
+	client.Query("Users").IndexName("ByName").Execute()
+	assert.Equal(CapacityIndexes, executor.QueryCall.ReturnConsumedCapacity)
+	client.UpdateItem("Users", HashKey("Id", 1)).ReturnConsumedCapacity(CapacityTotal).Execute()
+	assert.Equal(CapacityIndexes, executor.UpdateItemCall.ReturnConsumedCapacity)
+
+	snapshot := meter.Snapshot(0)
+	users := snapshot.Tables["Users"]
+	assert.Equal(CapacityUsage{ReadUnits: 3, WriteUnits: 1.5, Operations: 2}, users.CapacityUsage)
+	assert.Equal(&CapacityUsage{ReadUnits: 2, Operations: 1}, users.Indexes["ByName"])
+	assert.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), snapshot.Start)
+
+	// A minute later, the last minute only has the new query
+	clock.now = clock.now.Add(time.Minute)
+	client.Query("Users").Execute()
+	assert.Equal(3.0, meter.Snapshot(time.Second).Tables["Users"].ReadUnits)
+	assert.Equal(6.0, meter.Snapshot(0).Tables["Users"].ReadUnits)
+
+	// Old windows are dropped
+	clock.now = clock.now.Add(3 * time.Minute)
+	client.Query("Users").Execute()
+	assert.Equal(3.0, meter.Snapshot(0).Tables["Users"].ReadUnits)
+
+	// Windows past the retention are left out even without new records
+	clock.now = clock.now.Add(10 * time.Minute)
+	assert.Equal(0, len(meter.Snapshot(0).Tables))
+	clock.now = clock.now.Add(-10 * time.Minute)
+
+	meter.Reset()
+	assert.Equal(0, len(meter.Snapshot(0).Tables))
+}
+
+func TestCapacityMeterThreshold(t *testing.T) {
+	assert, meter, _, executor, client := meterSetUp(t)
+	var exceeded []ConsumedCapacity
+	meter.Threshold = 10
+	meter.OnThreshold = func(op *Operation, c ConsumedCapacity) {
+		assert.Equal("Scan", op.Name)
+		exceeded = append(exceeded, c)
+	}
+	executor.ScanResult = &ScanResult{ConsumedCapacity: &ConsumedCapacity{TableName: "Users", CapacityUnits: 5}}
+	client.Scan("Users").Execute()
+	assert.Equal(0, len(exceeded))
+
+	executor.ScanResult = &ScanResult{ConsumedCapacity: &ConsumedCapacity{TableName: "Users", CapacityUnits: 50}}
+	client.Scan("Users").Execute()
+	assert.Equal(1, len(exceeded))
+	assert.Equal(50.0, exceeded[0].CapacityUnits)
+}
@@ -10,10 +10,12 @@ NewAwsClient is a shortcut to create a new dynamo client set up for AWS executor
 region is the AWS region, e.g. us-east-1.
 accessKey is your amazon access key ID.
 secretKey is your amazon secret key ID.
+
+To configure timeouts, retries, proxies and the like, use
+NewAwsExecutorWithOptions instead.
 */
 func NewAwsClient(region string, accessKey string, secretKey string) *Client {
-	endpoint := "https://dynamodb." + region + ".amazonaws.com/"
-	return NewClient(NewAwsExecutor(endpoint, region, accessKey, secretKey))
+	return NewClient(NewAwsExecutor(awsEndpoint(region), region, accessKey, secretKey))
 }
 
 /*
@@ -21,9 +23,15 @@ NewClient creates a new client from an executor.
 
 For most use cases other than testing and mocking, you should be able to use
 NewAwsClient which is a shortcut for this
+
+Any middleware given wraps every operation made by the client, with the first
+middleware outermost.
 */
-func NewClient(executor Executor) *Client {
-	return &Client{executor, executor.SchemaExecutor()}
+func NewClient(executor Executor, middleware ...Middleware) *Client {
+	if len(middleware) > 0 {
+		executor = newMiddlewareExecutor(executor, middleware)
+	}
+	return &Client{executor: executor, schemaExecutor: executor.SchemaExecutor()}
 }
 
 /*
@@ -35,6 +43,8 @@ threads/goroutines, as are the operations involving chaining on the client.
 type Client struct {
 	executor       Executor
 	schemaExecutor SchemaExecutor
+	tables         tableCache
+	versions       versionAttributes
 }
 
 /*
@@ -120,7 +130,7 @@ func (c *Client) UpdateItem(table string, key Document) *UpdateItem {
 CreateTable makes a new table in your account.
 
 This is not a synchronous operation; the table may take some time before
-it is actually usable.
+it is actually usable. Use WaitUntilActive or WaitForTable to block until it is.
 */
 func (c *Client) CreateTable(req *schema.CreateRequest) (*schema.CreateResult, error) {
 	return c.schemaExecutor.CreateTable(req)
@@ -136,6 +146,34 @@ func (c *Client) DescribeTable(table string) (*schema.DescribeResponse, error) {
 	return c.schemaExecutor.DescribeTable(&schema.DescribeRequest{TableName: table})
 }
 
+/*
+UpdateTable changes the throughput, global secondary indexes, billing mode or
+stream settings of an existing table.
+
+Like CreateTable, this is not a synchronous operation; the table and any new
+indexes will spend some time in the UPDATING or CREATING state.
+*/
+func (c *Client) UpdateTable(req *schema.UpdateRequest) (*schema.UpdateResult, error) {
+	return c.schemaExecutor.UpdateTable(req)
+}
+
+/*
+UpdateTimeToLive enables or disables automatic expiry of items in a table.
+
+attribute names a Number attribute holding the expiry time in epoch seconds.
+*/
+func (c *Client) UpdateTimeToLive(table string, attribute string, enabled bool) (*schema.UpdateTTLResult, error) {
+	return c.schemaExecutor.UpdateTimeToLive(&schema.UpdateTTLRequest{
+		TableName:               table,
+		TimeToLiveSpecification: schema.TimeToLiveSpecification{AttributeName: attribute, Enabled: enabled},
+	})
+}
+
+// DescribeTimeToLive gets the time to live settings for a table.
+func (c *Client) DescribeTimeToLive(table string) (*schema.DescribeTTLResult, error) {
+	return c.schemaExecutor.DescribeTimeToLive(&schema.DescribeTTLRequest{TableName: table})
+}
+
 // ListTables paginates through all the tables in an account.
 func (c *Client) ListTables() *ListTables {
 	return &ListTables{client: c}
@@ -21,6 +21,15 @@ const (
 	SelectSpecificAttributes Select = "SPECIFIC_ATTRIBUTES"
 )
 
+// ItemCollectionMetricsDetail describes whether to get ItemCollectionMetrics.
+type ItemCollectionMetricsDetail string
+
+// Define all the ways you can request item collection metrics.
+const (
+	ItemCollectionMetricsSize ItemCollectionMetricsDetail = "SIZE"
+	ItemCollectionMetricsNone ItemCollectionMetricsDetail = "NONE"
+)
+
 // ReturnValues enable returning some or all changed data from put operations.
 type ReturnValues string
 
@@ -44,3 +44,49 @@ func (b BatchConsumedCapacity) GetTable(tableName string) *ConsumedCapacity {
 	}
 	return nil
 }
+
+// Levels of detail, so a request can be made to return at least some level.
+var capacityDetailLevels = map[CapacityDetail]int{CapacityTotal: 1, CapacityIndexes: 2}
+
+/*
+A copy of request with ReturnConsumedCapacity set to detail, unless it already
+asks for at least that much detail. Used by middleware needing capacity.
+*/
+func withConsumedCapacity(request interface{}, detail CapacityDetail) interface{} {
+	wanted := func(c CapacityDetail) bool { return capacityDetailLevels[c] < capacityDetailLevels[detail] }
+	switch r := request.(type) {
+	case *GetItem:
+		if wanted(r.req.ReturnConsumedCapacity) {
+			return r.ReturnConsumedCapacity(detail)
+		}
+	case *PutItem:
+		if wanted(r.req.ReturnConsumedCapacity) {
+			return r.ReturnConsumedCapacity(detail)
+		}
+	case *UpdateItem:
+		if wanted(r.req.ReturnConsumedCapacity) {
+			return r.ReturnConsumedCapacity(detail)
+		}
+	case *DeleteItem:
+		if wanted(r.req.ReturnConsumedCapacity) {
+			return r.ReturnConsumedCapacity(detail)
+		}
+	case *Query:
+		if wanted(r.req.ReturnConsumedCapacity) {
+			return r.ReturnConsumedCapacity(detail)
+		}
+	case *Scan:
+		if wanted(r.req.ReturnConsumedCapacity) {
+			return r.ReturnConsumedCapacity(detail)
+		}
+	case *BatchGet:
+		if wanted(r.capacityDetail) {
+			return r.ReturnConsumedCapacity(detail)
+		}
+	case *BatchWrite:
+		if wanted(r.capacityDetail) {
+			return r.ReturnConsumedCapacity(detail)
+		}
+	}
+	return request
+}
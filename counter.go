@@ -0,0 +1,176 @@
+package dynago
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+)
+
+/*
+Increment atomically adds delta to a numeric attribute of an item, and gives
+the new value. A missing item or attribute counts as zero, so the first
+increment creates it. delta may be negative.
+
+	views, err := client.Increment("Pages", dynago.HashKey("Url", url), "Views", 1)
+*/
+func (c *Client) Increment(table string, key Document, attribute string, delta int64) (int64, error) {
+	result, err := c.UpdateItem(table, key).
+		UpdateExpression("ADD #c :n", P("#c", attribute), P(":n", delta)).
+		ReturnValues(ReturnUpdatedNew).
+		Execute()
+	if err != nil {
+		return 0, err
+	} else if result == nil {
+		return 0, fmt.Errorf("dynago: no result incrementing %s", attribute)
+	}
+	return itemInt(result.Attributes, attribute)
+}
+
+/*
+Sequence hands out unique, increasing IDs from a counter item.
+
+Instead of incrementing the counter for every ID, a Sequence reserves a block
+of IDs at a time and hands them out from memory, so a block size of 100 means
+one write per 100 IDs. IDs start at 1. Any number of Sequences, in any number
+of processes, can share a counter and never hand out the same ID, but IDs from
+different Sequences interleave, and the unused part of a block is lost when
+the process exits, leaving gaps.
+
+A Sequence can be used from multiple goroutines.
+*/
+type Sequence struct {
+	client    *Client
+	table     string
+	key       Document
+	attribute string
+	blockSize int64
+
+	lock  sync.Mutex
+	next  int64
+	limit int64 // Next ID after the reserved block
+}
+
+/*
+NewSequence makes a Sequence using the attribute of the item with the given key
+as its counter, reserving blockSize IDs at a time.
+*/
+func NewSequence(client *Client, table string, key Document, attribute string, blockSize int64) *Sequence {
+	if blockSize < 1 {
+		blockSize = 1
+	}
+	return &Sequence{client: client, table: table, key: key, attribute: attribute, blockSize: blockSize}
+}
+
+// Next gives the next ID, reserving another block if needed.
+func (s *Sequence) Next() (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.next >= s.limit {
+		last, err := s.client.Increment(s.table, s.key, s.attribute, s.blockSize)
+		if err != nil {
+			return 0, err
+		}
+		s.next, s.limit = last-s.blockSize+1, last+1
+	}
+	id := s.next
+	s.next++
+	return id, nil
+}
+
+/*
+ShardedCounter is a counter spread over multiple items, for counters updated
+too often for a single item to keep up.
+
+Each shard is an item whose hash key is the counter's hash key value with
+"#" and the shard number appended, e.g. "pageviews#3", so the hash key must be
+a string. Adding picks a random shard, and reading sums all of them.
+*/
+type ShardedCounter struct {
+	table     *Table[Document]
+	key       Document
+	hashKey   string
+	rangeKey  string
+	attribute string
+	shards    int
+
+	random func(n int) int // Overridden in tests
+}
+
+/*
+NewShardedCounter makes a counter stored in the attribute of shards items. key
+is the key of the counter before sharding, made of the attributes hashKey and
+rangeKey. rangeKey is empty if the table has no range key; otherwise its value
+is the same for every shard.
+
+Changing the number of shards of an existing counter loses the counts in the
+shards which are dropped, so it should only be increased.
+*/
+func NewShardedCounter(client *Client, table string, key Document, hashKey, rangeKey, attribute string, shards int) *ShardedCounter {
+	if shards < 1 {
+		shards = 1
+	}
+	return &ShardedCounter{
+		table:     NewTable[Document](client, table, hashKey, rangeKey),
+		key:       key,
+		hashKey:   hashKey,
+		rangeKey:  rangeKey,
+		attribute: attribute,
+		shards:    shards,
+	}
+}
+
+// Add adds delta to a random shard of the counter.
+func (s *ShardedCounter) Add(delta int64) error {
+	random := rand.Intn
+	if s.random != nil {
+		random = s.random
+	}
+	key := s.table.Key(s.shardKey(random(s.shards)))
+	_, err := s.table.client.UpdateItem(s.table.Name(), key).
+		UpdateExpression("ADD #c :n", P("#c", s.attribute), P(":n", delta)).
+		Execute()
+	return err
+}
+
+// Value gives the value of the counter, reading all its shards.
+func (s *ShardedCounter) Value(ctx context.Context) (int64, error) {
+	keys := make([]Key, s.shards)
+	for i := range keys {
+		keys[i] = s.shardKey(i)
+	}
+	items, err := s.table.BatchGet(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, item := range items {
+		n, err := itemInt(item, s.attribute)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (s *ShardedCounter) shardKey(shard int) Key {
+	return Key{
+		Hash:  ShardKey(s.key[s.hashKey], shard),
+		Range: s.key[s.rangeKey],
+	}
+}
+
+// The value of an integer attribute, which is zero if the item or the attribute is missing.
+func itemInt(item Document, attribute string) (int64, error) {
+	value, ok := item[attribute]
+	if !ok || value == nil {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(numberString(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("dynago: attribute %s should be an integer, not %v", attribute, value)
+	}
+	return n, nil
+}
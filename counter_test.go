@@ -0,0 +1,68 @@
+package dynago
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIncrement(t *testing.T) {
+	assert, client, executor := setUp(t)
+	executor.UpdateItemResult = &UpdateItemResult{Attributes: Document{"Views": Number("42")}}
+	views, err := client.Increment("Pages", HashKey("Url", "/"), "Views", 2)
+	assert.NoError(err)
+	assert.Equal(int64(42), views)
+	call := executor.UpdateItemCall
+	assert.Equal("ADD #c :n", call.UpdateExpression)
+	assert.Equal(map[string]string{"#c": "Views"}, call.ExpressionAttributeNames)
+	assert.Equal(Document{":n": int64(2)}, call.ExpressionAttributeValues)
+	assert.Equal(ReturnUpdatedNew, call.ReturnValues)
+
+	executor.UpdateItemResult = &UpdateItemResult{Attributes: Document{"Views": "many"}}
+	_, err = client.Increment("Pages", HashKey("Url", "/"), "Views", 2)
+	assert.EqualError(err, "dynago: attribute Views should be an integer, not many")
+}
+
+func TestSequence(t *testing.T) {
+	assert, client, executor := setUp(t)
+	executor.Expect("UpdateItem").Match(func(call *MockExecutorCall) bool {
+		return call.ExpressionAttributeValues[":n"] == int64(3)
+	}).Return(&UpdateItemResult{Attributes: Document{"Next": Number("3")}})
+	executor.Expect("UpdateItem").Return(&UpdateItemResult{Attributes: Document{"Next": Number("9")}})
+
+	seq := NewSequence(client, "Counters", HashKey("Name", "orders"), "Next", 3)
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		id, err := seq.Next()
+		assert.NoError(err)
+		ids = append(ids, id)
+	}
+	// Another process reserved 4 to 6 in between.
+	assert.Equal([]int64{1, 2, 3, 7, 8}, ids)
+	assert.NoError(executor.ExpectationsMet())
+}
+
+func TestShardedCounter(t *testing.T) {
+	assert, client, executor := setUp(t)
+	counter := NewShardedCounter(client, "Stats", HashRangeKey("Name", "views", "Day", "2020-01-01"), "Name", "Day", "Count", 3)
+	counter.random = func(n int) int { return n - 1 }
+
+	assert.NoError(counter.Add(5))
+	assert.Equal(HashRangeKey("Name", "views#2", "Day", "2020-01-01"), executor.UpdateItemCall.Key)
+	assert.Equal(Document{":n": int64(5)}, executor.UpdateItemCall.ExpressionAttributeValues)
+
+	executor.BatchGetItemResult = &BatchGetResult{Responses: map[string][]Document{"Stats": {
+		{"Name": "views#0", "Day": "2020-01-01", "Count": Number("4")},
+		{"Name": "views#2", "Day": "2020-01-01", "Count": Number("5")},
+	}}}
+	total, err := counter.Value(context.Background())
+	assert.NoError(err)
+	assert.Equal(int64(9), total)
+	keys := executor.BatchGetItemCall.BatchGets["Stats"].Keys
+	assert.Equal(3, len(keys))
+	assert.Contains(keys, HashRangeKey("Name", "views#1", "Day", "2020-01-01"))
+
+	hashOnly := NewShardedCounter(client, "Stats", HashKey("Name", "likes"), "Name", "", "Count", 2)
+	hashOnly.random = func(n int) int { return n - 1 }
+	assert.NoError(hashOnly.Add(1))
+	assert.Equal(HashKey("Name", "likes#1"), executor.UpdateItemCall.Key)
+}
@@ -0,0 +1,264 @@
+package dynago
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownEntity is returned when decoding an item which isn't of a registered entity type.
+var ErrUnknownEntity = errors.New("dynago: unknown entity type")
+
+/*
+CompositeKey builds and parses key values made of several segments, as used in
+single-table designs, such as "ORDER#2024-01-01#9":
+
+	userKey := dynago.CompositeKey{Prefix: "USER"}
+	orderKey := dynago.CompositeKey{Prefix: "ORDER"}
+	sk := orderKey.Build(date, 9) // "ORDER#2024-01-01#9"
+	key := dynago.HashRangeKey("PK", userKey.Build(123), "SK", sk)
+
+	var date string
+	var id int
+	err := orderKey.Parse(sk, &date, &id)
+
+Segments can be strings, numbers, Number or time.Time, which is written in the
+same format as time attributes. Building with fewer segments than the key has
+gives a prefix of the key, for use with begins_with. Segments must not contain
+the delimiter.
+*/
+type CompositeKey struct {
+	Prefix    string // First segment, naming what the key is for. May be empty.
+	Delimiter string // Separates segments. Defaults to "#".
+
+	// Width, if set, zero-pads integer segments to this many digits so that
+	// non-negative numbers sort in numeric order.
+	Width int
+}
+
+// Build joins the prefix and the segments into a key value.
+func (k CompositeKey) Build(segments ...interface{}) string {
+	parts := make([]string, 0, len(segments)+1)
+	if k.Prefix != "" {
+		parts = append(parts, k.Prefix)
+	}
+	for _, segment := range segments {
+		parts = append(parts, k.format(segment))
+	}
+	return strings.Join(parts, k.delimiter())
+}
+
+/*
+Parse splits a key value into segments, storing them in the values pointed to
+by dest, which can be pointers to strings, numbers or time.Time. The key must
+have the prefix and exactly one segment for each dest.
+*/
+func (k CompositeKey) Parse(key string, dest ...interface{}) error {
+	parts := strings.Split(key, k.delimiter())
+	if k.Prefix != "" {
+		if parts[0] != k.Prefix {
+			return fmt.Errorf("dynago: key %q doesn't start with %q", key, k.Prefix)
+		}
+		parts = parts[1:]
+	}
+	if len(parts) != len(dest) {
+		return fmt.Errorf("dynago: key %q has %d segments, expected %d", key, len(parts), len(dest))
+	}
+	for i, part := range parts {
+		v := reflect.ValueOf(dest[i])
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return fmt.Errorf("dynago: cannot parse key segment into non-pointer %T", dest[i])
+		}
+		var value interface{} = part
+		switch v.Elem().Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			value = Number(part)
+		}
+		if err := unmarshalValue(value, v.Elem(), ""); err != nil {
+			return fmt.Errorf("dynago: cannot parse segment %q of key %q as %s", part, key, v.Elem().Type())
+		}
+	}
+	return nil
+}
+
+func (k CompositeKey) delimiter() string {
+	if k.Delimiter == "" {
+		return "#"
+	}
+	return k.Delimiter
+}
+
+func (k CompositeKey) format(segment interface{}) string {
+	switch v := segment.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(iso8601compact)
+	case int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8:
+		if k.Width > 0 {
+			return fmt.Sprintf("%0*d", k.Width, v)
+		}
+	}
+	if n := numberString(segment); n != "" {
+		return n
+	}
+	return fmt.Sprint(segment)
+}
+
+/*
+EntityRegistry maps the items of a single-table design to Go types, so that
+results with several kinds of item can be decoded:
+
+	registry := &dynago.EntityRegistry{KeyAttribute: "SK"}
+	registry.Register("PROFILE", User{})
+	registry.Register("ORDER", Order{})
+
+	values, err := registry.DecodeAll(result.Items)
+	for _, value := range values {
+		switch value := value.(type) {
+		case User:
+			...
+		case Order:
+			...
+		}
+	}
+
+The entity type of an item is the value of Attribute, or if Attribute isn't set
+or is missing from the item, the first segment of KeyAttribute.
+
+An EntityRegistry can be used from multiple goroutines.
+*/
+type EntityRegistry struct {
+	Attribute    string // Attribute holding the entity type, e.g. "Type"
+	KeyAttribute string // Key attribute starting with the entity type, e.g. "SK" with values like "ORDER#9"
+	Delimiter    string // Ends the entity type in KeyAttribute. Defaults to "#".
+
+	lock  sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}
+
+/*
+Register maps the entity type name to the type of example, which is normally a
+struct value. Pointers are registered as the type they point to.
+*/
+func (r *EntityRegistry) Register(name string, example interface{}) {
+	if example == nil {
+		panic("dynago: Register requires a non-nil example of entity type " + name)
+	}
+	t := reflect.TypeOf(example)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.types == nil {
+		r.types = make(map[string]reflect.Type)
+		r.names = make(map[reflect.Type]string)
+	}
+	r.types[name] = t
+	r.names[t] = name
+}
+
+// Entity gives the entity type name of an item, or "" if it has none.
+func (r *EntityRegistry) Entity(item Document) string {
+	if name, ok := item[r.Attribute].(string); ok && r.Attribute != "" {
+		return name
+	}
+	if key, ok := item[r.KeyAttribute].(string); ok && r.KeyAttribute != "" {
+		delimiter := r.Delimiter
+		if delimiter == "" {
+			delimiter = "#"
+		}
+		name, _, _ := strings.Cut(key, delimiter)
+		return name
+	}
+	return ""
+}
+
+// Decode converts an item into a value of the type registered for its entity type.
+func (r *EntityRegistry) Decode(item Document) (interface{}, error) {
+	t, name := r.entityType(item)
+	if t == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownEntity, name)
+	}
+	v := reflect.New(t)
+	if err := UnmarshalItem(item, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// DecodeAll decodes every item, in order, failing if any of them can't be decoded.
+func (r *EntityRegistry) DecodeAll(items []Document) ([]interface{}, error) {
+	values := make([]interface{}, len(items))
+	for i, item := range items {
+		value, err := r.Decode(item)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+/*
+MarshalItem converts a value of a registered type into a Document with
+MarshalItem, and sets Attribute, if there is one, to the entity type name.
+*/
+func (r *EntityRegistry) MarshalItem(value interface{}) (Document, error) {
+	t := reflect.TypeOf(value)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	r.lock.RLock()
+	name, ok := r.names[t]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %T", ErrUnknownEntity, value)
+	}
+	doc, err := MarshalItem(value)
+	if err == nil && r.Attribute != "" {
+		// A Document is given back as is, so copy it rather than change the caller's.
+		copied := make(Document, len(doc)+1)
+		for k, v := range doc {
+			copied[k] = v
+		}
+		copied[r.Attribute] = name
+		doc = copied
+	}
+	return doc, err
+}
+
+func (r *EntityRegistry) entityType(item Document) (reflect.Type, string) {
+	name := r.Entity(item)
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.types[name], name
+}
+
+/*
+DecodeEntities decodes the items registered as type T, skipping all others:
+
+	orders, err := dynago.DecodeEntities[Order](registry, result.Items)
+*/
+func DecodeEntities[T any](r *EntityRegistry, items []Document) ([]T, error) {
+	want := reflect.TypeOf((*T)(nil)).Elem()
+	var values []T
+	for _, item := range items {
+		if t, _ := r.entityType(item); t != want {
+			continue
+		}
+		var value T
+		if err := UnmarshalItem(item, &value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
@@ -0,0 +1,94 @@
+package dynago
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type entityUser struct {
+	PK   string
+	SK   string
+	Name string
+}
+
+type entityOrder struct {
+	PK    string
+	SK    string
+	Total int
+}
+
+func TestCompositeKey(t *testing.T) {
+	assert := assert.New(t)
+	orderKey := CompositeKey{Prefix: "ORDER"}
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := orderKey.Build(created, 9)
+	assert.Equal("ORDER#2024-01-01T12:00:00Z#9", key)
+	assert.Equal("ORDER#2024-01-01T12:00:00Z", orderKey.Build(created))
+
+	var parsed time.Time
+	var id int
+	assert.NoError(orderKey.Parse(key, &parsed, &id))
+	assert.Equal(created, parsed)
+	assert.Equal(9, id)
+
+	assert.EqualError(orderKey.Parse("USER#1", &id), `dynago: key "USER#1" doesn't start with "ORDER"`)
+	assert.EqualError(orderKey.Parse("ORDER#1", &parsed, &id), `dynago: key "ORDER#1" has 1 segments, expected 2`)
+	assert.EqualError(orderKey.Parse("ORDER#x", &id), `dynago: cannot parse segment "x" of key "ORDER#x" as int`)
+	assert.Error(orderKey.Parse("ORDER#1", id))
+
+	padded := CompositeKey{Delimiter: "|", Width: 5}
+	assert.Equal("00042|a|1.5|7", padded.Build(42, "a", 1.5, Number("7")))
+	var name string
+	var score float64
+	assert.NoError(padded.Parse("00042|a|1.5", &id, &name, &score))
+	assert.Equal(42, id)
+	assert.Equal("a", name)
+	assert.Equal(1.5, score)
+}
+
+func TestEntityRegistry(t *testing.T) {
+	assert := assert.New(t)
+	registry := &EntityRegistry{Attribute: "Type", KeyAttribute: "SK"}
+	registry.Register("PROFILE", entityUser{})
+	registry.Register("ORDER", &entityOrder{})
+
+	items := []Document{
+		{"PK": "USER#1", "SK": "PROFILE", "Name": "Bob"},
+		{"PK": "USER#1", "SK": "ORDER#2024-01-01#9", "Total": Number("15")},
+		{"PK": "USER#1", "SK": "X", "Type": "ORDER", "Total": Number("3")},
+	}
+	values, err := registry.DecodeAll(items)
+	assert.NoError(err)
+	assert.Equal([]interface{}{
+		entityUser{PK: "USER#1", SK: "PROFILE", Name: "Bob"},
+		entityOrder{PK: "USER#1", SK: "ORDER#2024-01-01#9", Total: 15},
+		entityOrder{PK: "USER#1", SK: "X", Total: 3},
+	}, values)
+
+	orders, err := DecodeEntities[entityOrder](registry, items)
+	assert.NoError(err)
+	assert.Equal(2, len(orders))
+
+	_, err = registry.DecodeAll(append(items, Document{"SK": "INVOICE#1"}))
+	assert.True(errors.Is(err, ErrUnknownEntity))
+	assert.EqualError(err, `dynago: unknown entity type "INVOICE"`)
+
+	doc, err := registry.MarshalItem(&entityOrder{PK: "USER#1", SK: "ORDER#1", Total: 5})
+	assert.NoError(err)
+	assert.Equal(Document{"PK": "USER#1", "SK": "ORDER#1", "Total": int64(5), "Type": "ORDER"}, doc)
+	_, err = registry.MarshalItem(tablePost{})
+	assert.True(errors.Is(err, ErrUnknownEntity))
+
+	// Documents aren't changed.
+	registry.Register("RAW", Document{})
+	raw := Document{"PK": "RAW#1"}
+	doc, err = registry.MarshalItem(raw)
+	assert.NoError(err)
+	assert.Equal(Document{"PK": "RAW#1", "Type": "RAW"}, doc)
+	assert.Equal(Document{"PK": "RAW#1"}, raw)
+
+	assert.PanicsWithValue("dynago: Register requires a non-nil example of entity type NONE", func() { registry.Register("NONE", nil) })
+}
@@ -1,26 +1,50 @@
 package dynago
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
+	"github.com/rmfarrell/dynago/internal/aws"
 	"github.com/rmfarrell/dynago/internal/codes"
 	"github.com/rmfarrell/dynago/internal/dynamodb"
 )
 
-// Error encapsulates errors coming from amazon/dynamodb.
-// It is returned whenever we get a non-2xx result from dynamodb.
+/*
+Error encapsulates errors coming from amazon/dynamodb.
+It is returned whenever we get a non-2xx result from dynamodb.
+
+Error unwraps to its Type, so the error codes can be used as sentinels:
+
+	if errors.Is(err, dynago.ErrorConditionFailed) {
+		// handle failed condition
+	}
+*/
 type Error struct {
 	Type          codes.ErrorCode // Parsed and mapped down type
 	AmazonRawType string          // Raw error type from amazon
 	Exception     string          // Exception from amazon
 	Message       string          // Raw message from amazon
-	Request       *http.Request   // If available, HTTP request
-	RequestBody   []byte          // If available, raw request body bytes
-	Response      *http.Response  // If available, HTTP response
-	ResponseBody  []byte          // If available, raw response body bytes
+	StatusCode    int             // HTTP status code of the response
+	RequestID     string          // Value of the x-amzn-RequestId header, quote this to AWS support
+	CRC32         string          // Value of the x-amz-crc32 header, if sent
+
+	// CancellationReasons explains each action of a canceled transaction,
+	// in the same order as the actions. Only set for ErrorTransactionCanceled.
+	CancellationReasons []CancellationReason
+
+	// Item is the existing item, for a failed condition when the request asked
+	// for it to be returned on condition check failure.
+	Item Document
+
+	Request      *http.Request  // If available, HTTP request
+	RequestBody  []byte         // If available, raw request body bytes
+	Response     *http.Response // If available, HTTP response
+	ResponseBody []byte         // If available, raw response body bytes
 }
 
 // Error formats this error as a string.
@@ -29,13 +53,112 @@ func (e *Error) Error() string {
 	if exception == "" {
 		exception = e.AmazonRawType
 	}
-	return fmt.Sprintf("dynago.Error(%s): %s: %s", e.Type, exception, e.Message)
+	s := fmt.Sprintf("dynago.Error(%s): %s: %s", e.Type.String(), exception, e.Message)
+	if e.RequestID != "" {
+		s += " (request ID " + e.RequestID + ")"
+	}
+	return s
+}
+
+// CancellationReason explains why a single action in a transaction was canceled.
+type CancellationReason struct {
+	Code    string   // e.g. "ConditionalCheckFailed", or "None" if this action was fine
+	Message string   `json:",omitempty"`
+	Item    Document `json:",omitempty"`
+}
+
+// Unwrap gives the ErrorCode, which allows errors.Is to match on error codes.
+func (e *Error) Unwrap() error {
+	return e.Type
+}
+
+// Throttling is true if the request was rejected for exceeding throughput or request rate limits.
+func (e *Error) Throttling() bool {
+	switch e.Type {
+	case ErrorThrottling, ErrorThroughputExceeded, ErrorRequestLimitExceeded:
+		return true
+	}
+	return false
+}
+
+/*
+Retryable is true if the same request may succeed if retried later, such as
+after throttling or an internal failure on the amazon side.
+*/
+func (e *Error) Retryable() bool {
+	switch e.Type {
+	case ErrorInternalFailure, ErrorServiceUnavailable, ErrorTransactionConflict, ErrorTransactionInProgress:
+		return true
+	}
+	return e.Throttling() || e.StatusCode >= 500
+}
+
+/*
+TransportError is returned when a request could not be made or the response
+could not be read, such as for timeouts and connection resets.
+
+This distinguishes network trouble from errors returned by DynamoDB itself,
+which are always of type *Error.
+*/
+type TransportError struct {
+	Request *http.Request // The request which failed
+	Err     error         // Underlying error from net/http
+}
+
+// Error formats this error as a string.
+func (e *TransportError) Error() string {
+	return "dynago: transport error: " + e.Err.Error()
+}
+
+// Unwrap gives the underlying error.
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout is true if the request timed out.
+func (e *TransportError) Timeout() bool {
+	var netErr net.Error
+	return errors.Is(e.Err, context.DeadlineExceeded) || (errors.As(e.Err, &netErr) && netErr.Timeout())
+}
+
+// Retryable is true unless the request was deliberately canceled.
+func (e *TransportError) Retryable() bool {
+	return !errors.Is(e.Err, context.Canceled)
+}
+
+/*
+Response integrity errors.
+
+These are returned wrapped in a *TransportError when a response from DynamoDB
+arrives corrupted or cut short, and are always retryable.
+*/
+var (
+	ErrChecksumMismatch  = aws.ErrChecksumMismatch
+	ErrTruncatedResponse = aws.ErrTruncatedResponse
+)
+
+// IsRetryable is true if err is an *Error or *TransportError which is retryable.
+func IsRetryable(err error) bool {
+	var e retryable
+	return errors.As(err, &e) && e.Retryable()
+}
+
+// IsThrottling is true if err is an *Error caused by throttling.
+func IsThrottling(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Throttling()
+}
+
+type retryable interface {
+	Retryable() bool
 }
 
 // Parse and create the error
 func (e *Error) parse(input *inputError) {
 	e.AmazonRawType = input.AmazonRawType
 	e.Message = input.Message
+	e.CancellationReasons = input.CancellationReasons
+	e.Item = input.Item
 	parts := strings.Split(e.AmazonRawType, "#")
 	if len(parts) >= 2 {
 		e.Exception = parts[1]
@@ -52,6 +175,11 @@ func buildError(req *http.Request, body []byte, response *http.Response, respBod
 		Response:     response,
 		ResponseBody: respBody,
 	}
+	if response != nil {
+		e.StatusCode = response.StatusCode
+		e.RequestID = response.Header.Get("x-amzn-RequestId")
+		e.CRC32 = response.Header.Get("x-amz-crc32")
+	}
 	dest := &inputError{}
 	if err := json.Unmarshal(respBody, dest); err == nil {
 		e.parse(dest)
@@ -61,9 +189,15 @@ func buildError(req *http.Request, body []byte, response *http.Response, respBod
 	return e
 }
 
+func buildTransportError(req *http.Request, err error) error {
+	return &TransportError{req, err}
+}
+
 type inputError struct {
-	AmazonRawType string `json:"__type"`
-	Message       string `json:"message"`
+	AmazonRawType       string `json:"__type"`
+	Message             string `json:"message"`
+	CancellationReasons []CancellationReason
+	Item                Document
 }
 
 // All the mapped error codes
@@ -85,6 +219,17 @@ const (
 
 	ErrorExpiredIterator // Iterator is no longer valid
 	ErrorTrimmedData     // Attempted to access data older than 24h
+
+	// Transactions
+	ErrorTransactionCanceled   // Transaction canceled; see CancellationReasons for why
+	ErrorTransactionConflict   // Another transaction is modifying the item
+	ErrorTransactionInProgress // Transaction with this client token is still being processed
+
+	ErrorRequestLimitExceeded         // Account-level request rate limits exceeded, try later
+	ErrorIdempotentParameterMismatch  // Client token reused with different request parameters
+	ErrorBackupInUse                  // Backup is being created, deleted or restored
+	ErrorContinuousBackupsUnavailable // Continuous backups or point in time recovery not available
+	ErrorDuplicateItem                // PartiQL insert of an item that already exists
 )
 
 var amazonErrorMap map[string]*dynamodb.ErrorConfig
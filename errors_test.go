@@ -1,9 +1,15 @@
 package dynago
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/rmfarrell/dynago/internal/codes"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,3 +40,129 @@ func TestErrorBuildError(t *testing.T) {
 	assert.Equal(ErrorUnknown, e.Type)
 	assert.Equal("unexpected end of JSON input", e.Message)
 }
+
+func TestErrorIs(t *testing.T) {
+	assert := assert.New(t)
+	var err error = &Error{Type: ErrorConditionFailed}
+	assert.True(errors.Is(err, ErrorConditionFailed))
+	assert.False(errors.Is(err, ErrorThrottling))
+	wrapped := fmt.Errorf("saving: %w", err)
+	assert.True(errors.Is(wrapped, ErrorConditionFailed))
+	var e *Error
+	assert.True(errors.As(wrapped, &e))
+	assert.Equal("dynago: ErrorConditionFailed", ErrorConditionFailed.Error())
+}
+
+func TestErrorClassification(t *testing.T) {
+	assert := assert.New(t)
+	check := func(e *Error, throttling, retryable bool) {
+		assert.Equal(throttling, e.Throttling(), e.Type.String())
+		assert.Equal(retryable, e.Retryable(), e.Type.String())
+		assert.Equal(throttling, IsThrottling(e))
+		assert.Equal(retryable, IsRetryable(fmt.Errorf("wrapped: %w", e)))
+	}
+	check(&Error{Type: ErrorThrottling}, true, true)
+	check(&Error{Type: ErrorThroughputExceeded}, true, true)
+	check(&Error{Type: ErrorInternalFailure}, false, true)
+	check(&Error{Type: ErrorServiceUnavailable}, false, true)
+	check(&Error{Type: ErrorUnknown, StatusCode: 502}, false, true)
+	check(&Error{Type: ErrorAuth, StatusCode: 400}, false, false)
+	check(&Error{Type: ErrorConditionFailed, StatusCode: 400}, false, false)
+	assert.False(IsRetryable(errors.New("other")))
+}
+
+func TestErrorResponseFields(t *testing.T) {
+	assert := assert.New(t)
+	body := []byte(`{"__type": "com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException", "message": "nope"}`)
+	checksum := fmt.Sprint(crc32.ChecksumIEEE(body))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-amzn-RequestId", "ABC123")
+		w.Header().Set("x-amz-crc32", checksum)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client := NewClient(NewAwsExecutor(server.URL, "us-east-1", "AKID", "SECRET"))
+	_, err := client.PutItem("table", HashKey("Id", 1)).Execute()
+	var e *Error
+	assert.True(errors.As(err, &e))
+	assert.Equal(ErrorConditionFailed, e.Type)
+	assert.Equal(400, e.StatusCode)
+	assert.Equal("ABC123", e.RequestID)
+	assert.Equal(checksum, e.CRC32)
+	assert.Equal("dynago.Error(ErrorConditionFailed): ConditionalCheckFailedException: nope (request ID ABC123)", e.Error())
+}
+
+func TestTransportError(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := NewClient(NewAwsExecutor(server.URL, "us-east-1", "AKID", "SECRET"))
+	_, err := client.GetItem("table", HashKey("Id", 1)).Execute()
+	var e *TransportError
+	assert.True(errors.As(err, &e))
+	assert.False(e.Timeout())
+	assert.True(IsRetryable(err))
+	assert.False(IsThrottling(err))
+
+	timeout := &TransportError{Err: context.DeadlineExceeded}
+	assert.True(timeout.Timeout())
+	assert.False((&TransportError{Err: context.Canceled}).Retryable())
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-amz-crc32", "12345")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(NewAwsExecutor(server.URL, "us-east-1", "AKID", "SECRET"))
+	_, err := client.GetItem("table", HashKey("Id", 1)).Execute()
+	var e *TransportError
+	assert.True(errors.As(err, &e))
+	assert.True(errors.Is(err, ErrChecksumMismatch))
+	assert.False(errors.Is(err, ErrTruncatedResponse))
+	assert.True(IsRetryable(err))
+}
+
+func TestErrorTransactionCanceled(t *testing.T) {
+	assert := assert.New(t)
+	input := `{
+		"__type": "com.amazonaws.dynamodb.v20120810#TransactionCanceledException",
+		"Message": "Transaction cancelled, please refer cancellation reasons for specific reasons [None, ConditionalCheckFailed]",
+		"CancellationReasons": [
+			{"Code": "None"},
+			{"Code": "ConditionalCheckFailed", "Message": "The conditional request failed", "Item": {"Id": {"N": "5"}}}
+		]
+	}`
+	e := buildError(nil, nil, nil, []byte(input)).(*Error)
+	assert.Equal(ErrorTransactionCanceled, e.Type)
+	assert.True(errors.Is(e, ErrorTransactionCanceled))
+	assert.Equal(2, len(e.CancellationReasons))
+	assert.Equal("None", e.CancellationReasons[0].Code)
+	assert.Equal("ConditionalCheckFailed", e.CancellationReasons[1].Code)
+	assert.Equal(Document{"Id": Number("5")}, e.CancellationReasons[1].Item)
+	assert.Contains(e.Message, "Transaction cancelled")
+}
+
+func TestErrorMappedCodes(t *testing.T) {
+	assert := assert.New(t)
+	check := func(exception string, code codes.ErrorCode) {
+		input := `{"__type": "com.amazonaws.dynamodb.v20120810#` + exception + `", "message": "x"}`
+		assert.Equal(code, buildError(nil, nil, nil, []byte(input)).(*Error).Type, exception)
+	}
+	check("TransactionConflictException", ErrorTransactionConflict)
+	check("RequestLimitExceeded", ErrorRequestLimitExceeded)
+	check("IdempotentParameterMismatchException", ErrorIdempotentParameterMismatch)
+	check("ItemCollectionSizeLimitExceeded", ErrorCollectionSizeExceeded)
+	check("TableNotFoundException", ErrorNotFound)
+	check("BackupInUseException", ErrorBackupInUse)
+	check("AccessDeniedException", ErrorAuth)
+	check("SomethingNewException", ErrorUnknown)
+	assert.True((&Error{Type: ErrorRequestLimitExceeded}).Throttling())
+	assert.True((&Error{Type: ErrorTransactionConflict}).Retryable())
+}
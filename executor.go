@@ -33,6 +33,9 @@ type SchemaExecutor interface {
 	DeleteTable(*schema.DeleteRequest) (*schema.DeleteResult, error)
 	DescribeTable(*schema.DescribeRequest) (*schema.DescribeResponse, error)
 	ListTables(*ListTables) (*schema.ListResponse, error)
+	UpdateTable(*schema.UpdateRequest) (*schema.UpdateResult, error)
+	UpdateTimeToLive(*schema.UpdateTTLRequest) (*schema.UpdateTTLResult, error)
+	DescribeTimeToLive(*schema.DescribeTTLRequest) (*schema.DescribeTTLResult, error)
 }
 
 // AwsRequester makes requests to dynamodb
@@ -40,6 +43,19 @@ type AwsRequester interface {
 	MakeRequest(target string, body []byte) ([]byte, error)
 }
 
+/*
+AttemptRequester is an AwsRequester which can also give the number of
+attempts a request took, including retries.
+
+AwsExecutor uses it to fill in Operation.Attempts for middleware. Requesters
+wrapping another should implement it by passing on the count of the wrapped
+requester.
+*/
+type AttemptRequester interface {
+	AwsRequester
+	MakeRequestAttempts(target string, body []byte) ([]byte, int, error)
+}
+
 // Create an AWS executor with a specified endpoint and AWS parameters.
 func NewAwsExecutor(endpoint, region, accessKey, secretKey string) *AwsExecutor {
 	signer := aws.AwsSigner{
@@ -48,13 +64,17 @@ func NewAwsExecutor(endpoint, region, accessKey, secretKey string) *AwsExecutor
 		SecretKey: secretKey,
 		Service:   "dynamodb",
 	}
+	if Debug.HasFlag(DebugAuth) {
+		signer.DebugFunc = DebugFunc
+	}
 	requester := &aws.RequestMaker{
-		Endpoint:       aws.FixEndpointUrl(endpoint),
-		Signer:         &signer,
-		BuildError:     buildError,
-		DebugRequests:  Debug.HasFlag(DebugRequests),
-		DebugResponses: Debug.HasFlag(DebugResponses),
-		DebugFunc:      DebugFunc,
+		Endpoint:           aws.FixEndpointUrl(endpoint),
+		Signer:             &signer,
+		BuildError:         buildError,
+		WrapTransportError: buildTransportError,
+		DebugRequests:      Debug.HasFlag(DebugRequests),
+		DebugResponses:     Debug.HasFlag(DebugResponses),
+		DebugFunc:          DebugFunc,
 	}
 	return &AwsExecutor{requester}
 }
@@ -70,11 +90,19 @@ type AwsExecutor struct {
 	Requester AwsRequester
 }
 
-func (e *AwsExecutor) makeRequest(target string, document interface{}) ([]byte, error) {
+// Make a request for request, counting its attempts toward the operation
+// running it, if any.
+func (e *AwsExecutor) send(request interface{}, target string, document interface{}) ([]byte, error) {
 	buf, err := json.Marshal(document)
 	if err != nil {
 		return nil, err
 	}
+	if r, ok := e.Requester.(AttemptRequester); ok {
+		respBody, attempts, err := r.MakeRequestAttempts(target, buf)
+		countAttempts(request, attempts)
+		return respBody, err
+	}
+	countAttempts(request, 1)
 	return e.Requester.MakeRequest(target, buf)
 }
 
@@ -86,7 +114,11 @@ This method is mostly exposed for those implementing custom executors or
 prototyping new functionality.
 */
 func (e *AwsExecutor) MakeRequestUnmarshal(method string, document interface{}, dest interface{}) (err error) {
-	body, err := e.makeRequest(method, document)
+	return e.sendUnmarshal(nil, method, document, dest)
+}
+
+func (e *AwsExecutor) sendUnmarshal(request interface{}, method string, document interface{}, dest interface{}) (err error) {
+	body, err := e.send(request, method, document)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,136 @@
+package dynago
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rmfarrell/dynago/internal/aws"
+)
+
+/*
+AwsExecutorOptions configures an AwsExecutor made by NewAwsExecutorWithOptions.
+
+Only Region, AccessKey and SecretKey are required. The zero value of every
+other field keeps the behavior of NewAwsExecutor.
+*/
+type AwsExecutorOptions struct {
+	Region    string
+	AccessKey string
+	SecretKey string
+
+	// Endpoint defaults to the public DynamoDB endpoint for Region. Set it to
+	// use a VPC endpoint or DynamoDB local.
+	Endpoint string
+
+	// HTTPClient is used as-is to make requests if set, and the transport
+	// options below are ignored.
+	HTTPClient *http.Client
+
+	// Transport is used to make requests if set, and MaxIdleConnsPerHost,
+	// Proxy and RootCAs are ignored. Otherwise a copy of
+	// http.DefaultTransport is configured with them.
+	Transport http.RoundTripper
+
+	MaxIdleConnsPerHost int                                   // Defaults to net/http's default of 2
+	Proxy               func(*http.Request) (*url.URL, error) // e.g. http.ProxyURL(u); defaults to http.ProxyFromEnvironment
+	RootCAs             *x509.CertPool                        // Defaults to the system roots
+
+	// AttemptTimeout limits each HTTP request, from connecting to reading
+	// the whole response body.
+	AttemptTimeout time.Duration
+
+	// TotalTimeout limits each call, including any retries.
+	TotalTimeout time.Duration
+
+	// MaxRetries is how many times a failed request is retried, if the error
+	// is retryable according to IsRetryable.
+	MaxRetries int
+
+	// MaxResponseSize defaults to 25MB.
+	MaxResponseSize int64
+
+	// Logger gets a debug record for each request, with the target, table,
+	// latency, status, request ID and body sizes. Failed requests are logged
+	// at warn level. Credentials are never logged.
+	Logger *slog.Logger
+
+	LogBodies    bool // Also log request and response bodies
+	RedactValues bool // Replace attribute values in logged bodies, keeping attribute names
+	LogSigning   bool // Log the canonical request and string to sign at debug level
+}
+
+/*
+NewAwsExecutorWithOptions creates an AWS executor with control over the
+HTTP transport, timeouts, retries and logging.
+
+Unlike NewAwsExecutor, the global Debug flags are ignored; use Logger instead.
+
+	executor := dynago.NewAwsExecutorWithOptions(dynago.AwsExecutorOptions{
+		Region:         "us-east-1",
+		AccessKey:      accessKey,
+		SecretKey:      secretKey,
+		Proxy:          http.ProxyURL(proxyURL),
+		AttemptTimeout: 2 * time.Second,
+		TotalTimeout:   10 * time.Second,
+		MaxRetries:     3,
+	})
+	client := dynago.NewClient(executor)
+*/
+func NewAwsExecutorWithOptions(opts AwsExecutorOptions) *AwsExecutor {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = awsEndpoint(opts.Region)
+	}
+	executor := NewAwsExecutor(endpoint, opts.Region, opts.AccessKey, opts.SecretKey)
+	requester := executor.Requester.(*aws.RequestMaker)
+	requester.Caller = opts.httpClient()
+	requester.AttemptTimeout = opts.AttemptTimeout
+	requester.TotalTimeout = opts.TotalTimeout
+	requester.MaxRetries = opts.MaxRetries
+	requester.ShouldRetry = IsRetryable
+	requester.MaxResponseSize = opts.MaxResponseSize
+	requester.Logger = opts.Logger
+	requester.LogBodies = opts.LogBodies
+	requester.RedactValues = opts.RedactValues
+	requester.DebugRequests, requester.DebugResponses = false, false
+	signer := requester.Signer.(*aws.AwsSigner)
+	signer.DebugFunc = nil
+	if opts.LogSigning {
+		signer.Logger = opts.Logger
+	}
+	return executor
+}
+
+func (opts *AwsExecutorOptions) httpClient() http.Client {
+	if opts.HTTPClient != nil {
+		return *opts.HTTPClient
+	}
+	if opts.Transport != nil {
+		return http.Client{Transport: opts.Transport}
+	}
+	if opts.MaxIdleConnsPerHost == 0 && opts.Proxy == nil && opts.RootCAs == nil {
+		return http.Client{}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.Proxy != nil {
+		transport.Proxy = opts.Proxy
+	}
+	if opts.RootCAs != nil {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.RootCAs = opts.RootCAs
+	}
+	return http.Client{Transport: transport}
+}
+
+func awsEndpoint(region string) string {
+	return "https://dynamodb." + region + ".amazonaws.com/"
+}
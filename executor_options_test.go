@@ -0,0 +1,151 @@
+package dynago
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rmfarrell/dynago/internal/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func optionsSetUp(t *testing.T, handler http.HandlerFunc, opts AwsExecutorOptions) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	opts.Endpoint = server.URL
+	opts.Region = "us-east-1"
+	return NewClient(NewAwsExecutorWithOptions(opts))
+}
+
+func TestNewAwsExecutorWithOptionsDefaults(t *testing.T) {
+	assert := assert.New(t)
+	executor := NewAwsExecutorWithOptions(AwsExecutorOptions{Region: "eu-west-1", AccessKey: "AKID", SecretKey: "SECRET"})
+	requester := executor.Requester.(*aws.RequestMaker)
+	assert.Equal("https://dynamodb.eu-west-1.amazonaws.com:443/", requester.Endpoint)
+	assert.Nil(requester.Caller.Transport)
+	assert.Equal(time.Duration(0), requester.AttemptTimeout)
+}
+
+func TestNewAwsExecutorWithOptionsTransport(t *testing.T) {
+	assert := assert.New(t)
+	proxyURL, _ := url.Parse("http://proxy.example.com:3128")
+	pool := x509.NewCertPool()
+	executor := NewAwsExecutorWithOptions(AwsExecutorOptions{
+		Region:              "us-east-1",
+		MaxIdleConnsPerHost: 50,
+		Proxy:               http.ProxyURL(proxyURL),
+		RootCAs:             pool,
+	})
+	transport := executor.Requester.(*aws.RequestMaker).Caller.Transport.(*http.Transport)
+	assert.Equal(50, transport.MaxIdleConnsPerHost)
+	assert.Equal(pool, transport.TLSClientConfig.RootCAs)
+	proxy, _ := transport.Proxy(&http.Request{})
+	assert.Equal(proxyURL, proxy)
+	assert.True(http.DefaultTransport != http.RoundTripper(transport))
+
+	client := &http.Client{Timeout: time.Second}
+	executor = NewAwsExecutorWithOptions(AwsExecutorOptions{Region: "us-east-1", HTTPClient: client, Proxy: http.ProxyURL(proxyURL)})
+	assert.Equal(*client, executor.Requester.(*aws.RequestMaker).Caller)
+}
+
+func TestNewAwsExecutorWithOptionsRootCAs(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Item": {"Id": {"N": "1"}}}`))
+	}))
+	defer server.Close()
+
+	opts := AwsExecutorOptions{Region: "us-east-1", Endpoint: server.URL}
+	_, err := NewClient(NewAwsExecutorWithOptions(opts)).GetItem("table", HashKey("Id", 1)).Execute()
+	assert.Error(err)
+
+	opts.RootCAs = x509.NewCertPool()
+	opts.RootCAs.AddCert(server.Certificate())
+	result, err := NewClient(NewAwsExecutorWithOptions(opts)).GetItem("table", HashKey("Id", 1)).Execute()
+	assert.NoError(err)
+	assert.Equal(Number("1"), result.Item["Id"])
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	assert := assert.New(t)
+	release := make(chan struct{})
+	defer close(release)
+	client := optionsSetUp(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}, AwsExecutorOptions{AttemptTimeout: 20 * time.Millisecond})
+
+	_, err := client.GetItem("table", HashKey("Id", 1)).Execute()
+	var e *TransportError
+	assert.True(errors.As(err, &e))
+	assert.True(e.Timeout())
+}
+
+func TestRetries(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	client := optionsSetUp(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"__type": "com.amazonaws.dynamodb.v20120810#InternalServerError", "message": "oops"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}, AwsExecutorOptions{MaxRetries: 3})
+
+	_, err := client.GetItem("table", HashKey("Id", 1)).Execute()
+	assert.NoError(err)
+	assert.Equal(int32(3), atomic.LoadInt32(&calls))
+
+	// Non-retryable errors are returned straight away
+	atomic.StoreInt32(&calls, 0)
+	client = optionsSetUp(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type": "com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException"}`))
+	}, AwsExecutorOptions{MaxRetries: 3})
+	_, err = client.GetItem("table", HashKey("Id", 1)).Execute()
+	assert.True(errors.Is(err, ErrorConditionFailed))
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestTotalTimeout(t *testing.T) {
+	assert := assert.New(t)
+	var calls int32
+	client := optionsSetUp(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, AwsExecutorOptions{MaxRetries: 100, TotalTimeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	_, err := client.GetItem("table", HashKey("Id", 1)).Execute()
+	assert.Error(err)
+	assert.True(time.Since(start) < time.Second)
+	assert.True(atomic.LoadInt32(&calls) < 100)
+}
+
+func TestNewAwsExecutorWithOptionsLogger(t *testing.T) {
+	assert := assert.New(t)
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := optionsSetUp(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type": "com.amazonaws.dynamodb.v20120810#ResourceNotFoundException"}`))
+	}, AwsExecutorOptions{Logger: logger, LogSigning: true})
+
+	client.GetItem("table", HashKey("Id", 1)).Execute()
+	assert.Contains(logs.String(), "level=DEBUG msg=\"dynago: signing request\"")
+	assert.Contains(logs.String(), "level=WARN msg=\"dynago: request\" target=DynamoDB_20120810.GetItem table=table")
+	assert.Contains(logs.String(), "status=400")
+	assert.NotContains(logs.String(), "request_body")
+
+	executor := NewAwsExecutorWithOptions(AwsExecutorOptions{Region: "us-east-1"})
+	assert.False(executor.Requester.(*aws.RequestMaker).DebugRequests)
+}
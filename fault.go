@@ -0,0 +1,337 @@
+package dynago
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/rmfarrell/dynago/internal/codes"
+	"github.com/rmfarrell/dynago/internal/dynamodb"
+)
+
+// Errors injected by a FaultInjector when Errors isn't set.
+var defaultFaultErrors = []error{ErrorThrottling, ErrorThroughputExceeded, ErrorInternalFailure}
+
+/*
+Fault describes the faults to inject into a single call.
+*/
+type Fault struct {
+	// Error fails the call without executing it. An error code such as
+	// ErrorThrottling is returned as an *Error like one from DynamoDB;
+	// other errors are returned as-is.
+	Error error
+
+	// Unprocessed is how many items of a BatchGet or BatchWrite are left out
+	// of the call and returned as unprocessed, starting from the last item.
+	Unprocessed int
+
+	// Truncate limits a Query or Scan page to this many items, so that more
+	// pages are needed to get all results.
+	Truncate uint
+
+	// Latency is added before the call.
+	Latency time.Duration
+}
+
+/*
+FaultInjector is an Executor which injects faults into calls made through
+another executor, to test retry and fallback logic:
+
+	faults := &dynago.FaultInjector{
+		Executor:        executor,
+		Seed:            42,
+		ErrorRate:       0.1,
+		UnprocessedRate: 0.2,
+		TruncateRate:    0.5,
+	}
+	client := dynago.NewClient(faults)
+
+Faults are chosen at random using the rates, from a random source seeded with
+Seed, so a test making the same calls gets the same faults every run. For
+exact control, set Schedule.
+
+Schema calls are passed through without faults.
+*/
+type FaultInjector struct {
+	Executor Executor
+	Seed     int64
+
+	ErrorRate float64 // Probability that a call fails
+	Errors    []error // Errors chosen from at random; defaults to ErrorThrottling, ErrorThroughputExceeded and ErrorInternalFailure
+
+	UnprocessedRate float64 // Probability that each item in a batch is returned unprocessed
+
+	TruncateRate  float64 // Probability that a Query or Scan page is truncated
+	TruncateLimit uint    // Items in a truncated page; defaults to 1
+
+	Latency       time.Duration // Added to every call
+	LatencyJitter time.Duration // Up to this much more latency is added at random
+
+	// Schedule, if set, gives the faults for each call instead of the rates
+	// above. call counts the calls of each method, starting from 0.
+	Schedule func(method string, call int) Fault
+
+	lock   sync.Mutex
+	random *rand.Rand
+	calls  map[string]int
+
+	sleep func(time.Duration) // Overridden in tests
+}
+
+// The faults for the next call to method. items is the size of a batch.
+func (f *FaultInjector) next(method string, items int) Fault {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+		f.random = rand.New(rand.NewSource(f.Seed))
+	}
+	call := f.calls[method]
+	f.calls[method]++
+	if f.Schedule != nil {
+		return f.Schedule(method, call)
+	}
+
+	fault := Fault{Latency: f.Latency}
+	if f.LatencyJitter > 0 {
+		fault.Latency += time.Duration(f.random.Int63n(int64(f.LatencyJitter)))
+	}
+	if f.ErrorRate > 0 && f.random.Float64() < f.ErrorRate {
+		errs := f.Errors
+		if len(errs) == 0 {
+			errs = defaultFaultErrors
+		}
+		fault.Error = errs[f.random.Intn(len(errs))]
+	}
+	if f.UnprocessedRate > 0 {
+		for i := 0; i < items; i++ {
+			if f.random.Float64() < f.UnprocessedRate {
+				fault.Unprocessed++
+			}
+		}
+	}
+	if f.TruncateRate > 0 && f.random.Float64() < f.TruncateRate {
+		fault.Truncate = f.TruncateLimit
+		if fault.Truncate == 0 {
+			fault.Truncate = 1
+		}
+	}
+	return fault
+}
+
+// Add latency and give the error to fail with, if any.
+func (f *FaultInjector) inject(fault Fault) error {
+	if fault.Latency > 0 {
+		if f.sleep != nil {
+			f.sleep(fault.Latency)
+		} else {
+			time.Sleep(fault.Latency)
+		}
+	}
+	if code, ok := fault.Error.(codes.ErrorCode); ok {
+		return faultError(code)
+	}
+	return fault.Error
+}
+
+// Make an error like DynamoDB would return for an error code.
+func faultError(code codes.ErrorCode) *Error {
+	e := &Error{Type: code, Message: "injected fault", StatusCode: 400}
+	for _, conf := range dynamodb.MappedErrors {
+		if conf.MappedError == code {
+			e.Exception, e.StatusCode = conf.AmazonCode, conf.ExpectedStatus
+			e.AmazonRawType = "com.amazonaws.dynamodb.v20120810#" + conf.AmazonCode
+			break
+		}
+	}
+	return e
+}
+
+func (f *FaultInjector) BatchGetItem(batchGet *BatchGet) (*BatchGetResult, error) {
+	fault := f.next("BatchGetItem", countBatchActions(batchGet.gets))
+	if err := f.inject(fault); err != nil {
+		return nil, err
+	}
+	if fault.Unprocessed <= 0 {
+		return f.Executor.BatchGetItem(batchGet)
+	}
+
+	tables := batchGet.buildTableMap()
+	unprocessed := BatchGetTableMap{}
+	kept := *batchGet
+	defer sameOperation(batchGet, &kept)()
+	kept.gets = splitBatchActions(batchGet.gets, fault.Unprocessed, func(action *batchAction) {
+		entry := unprocessed[action.table]
+		if entry == nil {
+			copied := *tables[action.table]
+			copied.Keys = nil
+			entry = &copied
+			unprocessed[action.table] = entry
+		}
+		entry.Keys = append(entry.Keys, action.item)
+	})
+
+	result := &BatchGetResult{}
+	if kept.gets != nil {
+		var err error
+		if result, err = f.Executor.BatchGetItem(&kept); err != nil {
+			return result, err
+		}
+	}
+	if result == nil {
+		result = &BatchGetResult{}
+	}
+	if result.UnprocessedKeys == nil {
+		result.UnprocessedKeys = BatchGetTableMap{}
+	}
+	for table, entry := range unprocessed {
+		if existing := result.UnprocessedKeys[table]; existing != nil {
+			existing.Keys = append(existing.Keys, entry.Keys...)
+		} else {
+			result.UnprocessedKeys[table] = entry
+		}
+	}
+	return result, nil
+}
+
+func (f *FaultInjector) BatchWriteItem(batchWrite *BatchWrite) (*BatchWriteResult, error) {
+	fault := f.next("BatchWriteItem", countBatchActions(batchWrite.puts)+countBatchActions(batchWrite.deletes))
+	if err := f.inject(fault); err != nil {
+		return nil, err
+	}
+	if fault.Unprocessed <= 0 {
+		return f.Executor.BatchWriteItem(batchWrite)
+	}
+
+	unprocessed := BatchWriteTableMap{}
+	kept := *batchWrite
+	defer sameOperation(batchWrite, &kept)()
+	remaining := fault.Unprocessed - countBatchActions(batchWrite.deletes)
+	kept.deletes = splitBatchActions(batchWrite.deletes, fault.Unprocessed, func(action *batchAction) {
+		entry := &BatchWriteTableEntry{}
+		entry.SetDelete(action.item)
+		unprocessed[action.table] = append(unprocessed[action.table], entry)
+	})
+	if remaining > 0 {
+		kept.puts = splitBatchActions(batchWrite.puts, remaining, func(action *batchAction) {
+			entry := &BatchWriteTableEntry{}
+			entry.SetPut(action.item)
+			unprocessed[action.table] = append(unprocessed[action.table], entry)
+		})
+	}
+
+	result := &BatchWriteResult{}
+	if kept.puts != nil || kept.deletes != nil {
+		var err error
+		if result, err = f.Executor.BatchWriteItem(&kept); err != nil {
+			return result, err
+		}
+	}
+	if result == nil {
+		result = &BatchWriteResult{}
+	}
+	if result.UnprocessedItems == nil {
+		result.UnprocessedItems = BatchWriteTableMap{}
+	}
+	for table, entries := range unprocessed {
+		result.UnprocessedItems[table] = append(result.UnprocessedItems[table], entries...)
+	}
+	return result, nil
+}
+
+func (f *FaultInjector) DeleteItem(deleteItem *DeleteItem) (*DeleteItemResult, error) {
+	if err := f.inject(f.next("DeleteItem", 0)); err != nil {
+		return nil, err
+	}
+	return f.Executor.DeleteItem(deleteItem)
+}
+
+func (f *FaultInjector) GetItem(getItem *GetItem) (*GetItemResult, error) {
+	if err := f.inject(f.next("GetItem", 0)); err != nil {
+		return nil, err
+	}
+	return f.Executor.GetItem(getItem)
+}
+
+func (f *FaultInjector) PutItem(putItem *PutItem) (*PutItemResult, error) {
+	if err := f.inject(f.next("PutItem", 0)); err != nil {
+		return nil, err
+	}
+	return f.Executor.PutItem(putItem)
+}
+
+func (f *FaultInjector) Query(query *Query) (*QueryResult, error) {
+	fault := f.next("Query", 0)
+	if err := f.inject(fault); err != nil {
+		return nil, err
+	}
+	if fault.Truncate == 0 || (query.req.Limit > 0 && query.req.Limit <= fault.Truncate) {
+		return f.Executor.Query(query)
+	}
+	truncated := *query
+	truncated.req.Limit = fault.Truncate
+	defer sameOperation(query, &truncated)()
+	result, err := f.Executor.Query(&truncated)
+	if result != nil {
+		// Later pages are fetched with the original limit.
+		result.query = query
+	}
+	return result, err
+}
+
+func (f *FaultInjector) Scan(scan *Scan) (*ScanResult, error) {
+	fault := f.next("Scan", 0)
+	if err := f.inject(fault); err != nil {
+		return nil, err
+	}
+	if fault.Truncate == 0 || (scan.req.Limit > 0 && scan.req.Limit <= fault.Truncate) {
+		return f.Executor.Scan(scan)
+	}
+	truncated := *scan
+	truncated.req.Limit = fault.Truncate
+	defer sameOperation(scan, &truncated)()
+	result, err := f.Executor.Scan(&truncated)
+	if result != nil {
+		result.req = scan
+	}
+	return result, err
+}
+
+func (f *FaultInjector) UpdateItem(update *UpdateItem) (*UpdateItemResult, error) {
+	if err := f.inject(f.next("UpdateItem", 0)); err != nil {
+		return nil, err
+	}
+	return f.Executor.UpdateItem(update)
+}
+
+func (f *FaultInjector) SchemaExecutor() SchemaExecutor {
+	return f.Executor.SchemaExecutor()
+}
+
+func countBatchActions(actions *batchAction) (n int) {
+	for a := actions; a != nil; a = a.next {
+		n++
+	}
+	return
+}
+
+/*
+Split a list of batch actions, giving the last n items (which are at the head
+of the list) to skip and returning a new list of the rest.
+*/
+func splitBatchActions(actions *batchAction, n int, skip func(*batchAction)) *batchAction {
+	var kept []*batchAction
+	for a := actions; a != nil; a = a.next {
+		if n > 0 {
+			skip(a)
+			n--
+		} else {
+			kept = append(kept, a)
+		}
+	}
+	var head *batchAction
+	for i := len(kept) - 1; i >= 0; i-- {
+		head = &batchAction{head, kept[i].table, kept[i].item}
+	}
+	return head
+}
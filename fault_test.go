@@ -0,0 +1,125 @@
+package dynago
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func faultSetUp(t *testing.T, schedule func(method string, call int) Fault) (*assert.Assertions, *Client, *MockExecutor) {
+	assert, _, executor := setUp(t)
+	faults := &FaultInjector{Executor: executor, Schedule: schedule}
+	return assert, NewClient(faults), executor
+}
+
+func TestFaultInjectorErrors(t *testing.T) {
+	failure := errors.New("connection reset")
+	assert, client, executor := faultSetUp(t, func(method string, call int) Fault {
+		switch call {
+		case 0:
+			return Fault{Error: ErrorThrottling}
+		case 1:
+			return Fault{Error: failure}
+		}
+		return Fault{}
+	})
+	executor.GetItemResult = &GetItemResult{Item: Document{"Id": 1}}
+
+	_, err := client.GetItem("table1", HashKey("Id", 1)).Execute()
+	var e *Error
+	if assert.True(errors.As(err, &e)) {
+		assert.Equal(ErrorThrottling, e.Type)
+		assert.Equal("ThrottlingException", e.Exception)
+		assert.Equal(400, e.StatusCode)
+		assert.True(e.Retryable())
+	}
+	_, err = client.GetItem("table1", HashKey("Id", 1)).Execute()
+	assert.Equal(failure, err)
+	assert.Equal(false, executor.GetItemCalled)
+
+	result, err := client.GetItem("table1", HashKey("Id", 1)).Execute()
+	assert.NoError(err)
+	assert.Equal(Document{"Id": 1}, result.Item)
+}
+
+func TestFaultInjectorBatchWrite(t *testing.T) {
+	assert, client, executor := faultSetUp(t, func(method string, call int) Fault {
+		return Fault{Unprocessed: 2}
+	})
+	result, err := client.BatchWrite().
+		Put("table1", Document{"Id": 1}, Document{"Id": 2}).
+		Delete("table1", HashKey("Id", 3)).
+		Execute()
+	assert.NoError(err)
+	assert.Equal([]Document{{"Id": 1}}, executor.BatchWriteItemCall.BatchWrites.GetPuts("table1"))
+	assert.Empty(executor.BatchWriteItemCall.BatchWrites.GetDeleteKeys("table1"))
+	assert.Equal([]Document{HashKey("Id", 3)}, result.UnprocessedItems.GetDeleteKeys("table1"))
+	assert.Equal([]Document{{"Id": 2}}, result.UnprocessedItems.GetPuts("table1"))
+}
+
+func TestFaultInjectorBatchGet(t *testing.T) {
+	assert, client, executor := faultSetUp(t, func(method string, call int) Fault {
+		return Fault{Unprocessed: 5}
+	})
+	result, err := client.BatchGet().
+		Get("table1", HashKey("Id", 1), HashKey("Id", 2)).
+		ConsistentRead("table1", true).
+		Execute()
+	assert.NoError(err)
+	assert.Equal(false, executor.BatchGetItemCalled)
+	if assert.NotNil(result.UnprocessedKeys["table1"]) {
+		assert.Equal([]Document{HashKey("Id", 2), HashKey("Id", 1)}, result.UnprocessedKeys["table1"].Keys)
+		assert.Equal(true, result.UnprocessedKeys["table1"].ConsistentRead)
+	}
+}
+
+func TestFaultInjectorTruncate(t *testing.T) {
+	assert, client, executor := faultSetUp(t, func(method string, call int) Fault {
+		if call == 0 {
+			return Fault{Truncate: 1}
+		}
+		return Fault{}
+	})
+	executor.QueryResult = &QueryResult{Items: []Document{{"Id": 1}}, LastEvaluatedKey: HashKey("Id", 1)}
+	result, err := client.Query("table1").Limit(10).Execute()
+	assert.NoError(err)
+	assert.Equal(uint(1), executor.QueryCall.Limit)
+
+	executor.QueryResult = &QueryResult{}
+	_, err = result.Next().Execute()
+	assert.NoError(err)
+	assert.Equal(uint(10), executor.QueryCall.Limit)
+	assert.Equal(HashKey("Id", 1), executor.QueryCall.ExclusiveStartKey)
+}
+
+func TestFaultInjectorSeed(t *testing.T) {
+	assert := assert.New(t)
+	run := func() (errs []bool, sleeps []time.Duration) {
+		executor := &MockExecutor{GetItemResult: &GetItemResult{}}
+		faults := &FaultInjector{
+			Executor:      executor,
+			Seed:          7,
+			ErrorRate:     0.5,
+			Latency:       time.Millisecond,
+			LatencyJitter: time.Millisecond,
+			sleep:         func(d time.Duration) { sleeps = append(sleeps, d) },
+		}
+		client := NewClient(faults)
+		for i := 0; i < 20; i++ {
+			_, err := client.GetItem("table1", HashKey("Id", i)).Execute()
+			errs = append(errs, err != nil)
+		}
+		return
+	}
+	errs1, sleeps1 := run()
+	errs2, sleeps2 := run()
+	assert.Equal(errs1, errs2)
+	assert.Equal(sleeps1, sleeps2)
+	assert.Contains(errs1, true)
+	assert.Contains(errs1, false)
+	for _, d := range sleeps1 {
+		assert.True(d >= time.Millisecond && d < 2*time.Millisecond)
+	}
+}
@@ -60,9 +60,11 @@ func makeTables(t *testing.T, client *dynago.Client) {
 	for _, table := range tables {
 		_, err := client.CreateTable(table)
 		if err != nil {
-			if e, ok := err.(*dynago.Error); ok && e.Type == dynago.ErrorResourceInUse {
-				continue
+			if e, ok := err.(*dynago.Error); !ok || e.Type != dynago.ErrorResourceInUse {
+				panic(err)
 			}
+		}
+		if _, err := client.WaitUntilActive(table.TableName); err != nil {
 			panic(err)
 		}
 	}
@@ -2,6 +2,7 @@ package dynago
 
 import (
 	"log"
+	"strconv"
 	"strings"
 )
 
@@ -46,6 +47,28 @@ func (e *expressionAttributes) assignParams(params []Param) {
 	}
 }
 
+/*
+Set a param to value with a placeholder made from base, which isn't already in
+use, and give the placeholder.
+*/
+func (e *expressionAttributes) placeholder(base string, value interface{}) string {
+	key := base
+	for i := 2; e.hasParam(key); i++ {
+		key = base + strconv.Itoa(i)
+	}
+	e.paramHelper(key, value)
+	return key
+}
+
+func (e *expressionAttributes) hasParam(key string) bool {
+	if strings.HasPrefix(key, "#") {
+		_, ok := e.ExpressionAttributeNames[key]
+		return ok
+	}
+	_, ok := e.ExpressionAttributeValues[key]
+	return ok
+}
+
 func paramCopy(doc *Document, extendBy int) Document {
 	params := make(Document, len(*doc)+extendBy)
 	if *doc != nil {
@@ -75,6 +98,9 @@ This is a set of bit-flags you can use to set up how much debugging dynago uses:
 Debug flags are copied into any executors, requesters, etc at creation time so
 the flags must be set before creating any Executor or client for them to take
 effect.
+
+As these flags are global, prefer setting AwsExecutorOptions.Logger to debug
+a single client.
 */
 var Debug DebugFlags
 
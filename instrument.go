@@ -0,0 +1,226 @@
+package dynago
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Attribute names, following the OpenTelemetry semantic conventions for DynamoDB.
+const (
+	AttrDBSystem         = "db.system"
+	AttrDBOperation      = "db.operation.name"
+	AttrTableNames       = "aws.dynamodb.table_names"
+	AttrIndexName        = "aws.dynamodb.index_name"
+	AttrConsumedCapacity = "aws.dynamodb.consumed_capacity"
+	AttrCount            = "aws.dynamodb.count"
+	AttrScannedCount     = "aws.dynamodb.scanned_count"
+	AttrErrorType        = "error.type"
+	AttrResendCount      = "http.request.resend_count"
+)
+
+// Attribute is a key and value attached to a span or measurement.
+type Attribute struct {
+	Key   string
+	Value interface{} // string, int, float64 or []string
+}
+
+/*
+Tracer starts spans. It is a small subset of OpenTelemetry's trace.Tracer,
+so adapting one only needs converting attributes.
+*/
+type Tracer interface {
+	Start(name string, attributes ...Attribute) Span
+}
+
+// Span is a single traced operation.
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Histogram records a distribution of values, like OpenTelemetry's Float64Histogram.
+type Histogram interface {
+	Record(value float64, attributes ...Attribute)
+}
+
+/*
+Instrumentation configures the middleware made by Instrument.
+Any of the fields may be left nil to skip that instrumentation.
+*/
+type Instrumentation struct {
+	Tracer Tracer
+
+	// Latency records the duration of each operation, in seconds.
+	Latency Histogram
+
+	// Capacity records consumed capacity units of each operation, per table.
+	// Capacity is only known if ReturnConsumedCapacity was requested.
+	Capacity Histogram
+
+	// Retries records how many times each operation was retried. Retries are
+	// only known when the client's executor reports Operation.Attempts.
+	Retries Histogram
+}
+
+/*
+Instrument makes a middleware which traces each operation and records its
+latency, consumed capacity and retries.
+
+Each span is named like "Query Users" and has attributes for the operation,
+table, index, consumed capacity, item counts, retries and error type where
+relevant.
+Measurements have the operation, table and error type as attributes.
+
+	client := dynago.NewClient(executor, dynago.Instrument(dynago.Instrumentation{
+		Tracer:  myTracer,
+		Latency: myLatencyHistogram,
+	}))
+*/
+func Instrument(i Instrumentation) Middleware {
+	return func(next Invoker) Invoker {
+		return func(op *Operation) (interface{}, error) {
+			attributes := []Attribute{
+				{AttrDBSystem, "dynamodb"},
+				{AttrDBOperation, op.Name},
+			}
+			if op.Table != "" {
+				attributes = append(attributes, Attribute{AttrTableNames, []string{op.Table}})
+			}
+			var span Span
+			if i.Tracer != nil {
+				spanAttributes := append([]Attribute(nil), attributes...)
+				if op.Index != "" {
+					spanAttributes = append(spanAttributes, Attribute{AttrIndexName, op.Index})
+				}
+				span = i.Tracer.Start(spanName(op), spanAttributes...)
+			}
+			start := time.Now()
+			result, err := next(op)
+			elapsed := time.Since(start)
+
+			capacity := resultCapacity(result)
+			if err != nil {
+				attributes = append(attributes, Attribute{AttrErrorType, errorType(err)})
+			}
+			if span != nil {
+				resultAttrs := resultAttributes(result, capacity)
+				if op.Attempts > 1 {
+					resultAttrs = append(resultAttrs, Attribute{AttrResendCount, op.Attempts - 1})
+				}
+				if err != nil {
+					span.RecordError(err)
+					resultAttrs = append(resultAttrs, attributes[len(attributes)-1])
+				}
+				span.SetAttributes(resultAttrs...)
+				span.End()
+			}
+			if i.Latency != nil {
+				i.Latency.Record(elapsed.Seconds(), attributes...)
+			}
+			if i.Retries != nil && op.Attempts > 0 {
+				i.Retries.Record(float64(op.Attempts-1), attributes...)
+			}
+			if i.Capacity != nil {
+				for _, c := range capacity {
+					i.Capacity.Record(c.CapacityUnits, Attribute{AttrDBOperation, op.Name}, Attribute{AttrTableNames, []string{c.TableName}})
+				}
+			}
+			return result, err
+		}
+	}
+}
+
+func spanName(op *Operation) string {
+	if op.Table == "" {
+		return op.Name
+	}
+	return op.Name + " " + op.Table
+}
+
+// The consumed capacity from the result of an operation, if there is any.
+func resultCapacity(result interface{}) []ConsumedCapacity {
+	var single *ConsumedCapacity
+	switch r := result.(type) {
+	case *GetItemResult:
+		if r != nil {
+			single = r.ConsumedCapacity
+		}
+	case *PutItemResult:
+		if r != nil {
+			single = r.ConsumedCapacity
+		}
+	case *UpdateItemResult:
+		if r != nil {
+			single = r.ConsumedCapacity
+		}
+	case *DeleteItemResult:
+		if r != nil {
+			single = r.ConsumedCapacity
+		}
+	case *QueryResult:
+		if r != nil {
+			single = r.ConsumedCapacity
+		}
+	case *ScanResult:
+		if r != nil {
+			single = r.ConsumedCapacity
+		}
+	case *BatchGetResult:
+		if r != nil {
+			return r.ConsumedCapacity
+		}
+	case *BatchWriteResult:
+		if r != nil {
+			return r.ConsumedCapacity
+		}
+	}
+	if single != nil {
+		return []ConsumedCapacity{*single}
+	}
+	return nil
+}
+
+func resultAttributes(result interface{}, capacity []ConsumedCapacity) (attributes []Attribute) {
+	if len(capacity) > 0 {
+		encoded := make([]string, len(capacity))
+		for i, c := range capacity {
+			b, _ := json.Marshal(c)
+			encoded[i] = string(b)
+		}
+		attributes = append(attributes, Attribute{AttrConsumedCapacity, encoded})
+	}
+	switch r := result.(type) {
+	case *QueryResult:
+		if r != nil {
+			attributes = append(attributes, Attribute{AttrCount, r.Count}, Attribute{AttrScannedCount, r.ScannedCount})
+		}
+	case *ScanResult:
+		if r != nil {
+			attributes = append(attributes, Attribute{AttrCount, len(r.Items)})
+		}
+	case *BatchGetResult:
+		if r != nil {
+			count := 0
+			for _, items := range r.Responses {
+				count += len(items)
+			}
+			attributes = append(attributes, Attribute{AttrCount, count})
+		}
+	}
+	return
+}
+
+// The error.type attribute: the error code for DynamoDB errors.
+func errorType(err error) string {
+	var e *Error
+	var t *TransportError
+	switch {
+	case errors.As(err, &e):
+		return e.Type.String()
+	case errors.As(err, &t):
+		return "TransportError"
+	}
+	return "_OTHER"
+}
@@ -0,0 +1,182 @@
+package dynago_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rmfarrell/dynago"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryTracer keeps spans in memory, like an OpenTelemetry in-memory exporter.
+type memoryTracer struct {
+	spans []*memorySpan
+}
+
+type memorySpan struct {
+	name       string
+	attributes map[string]interface{}
+	errors     []error
+	ended      bool
+}
+
+func (t *memoryTracer) Start(name string, attributes ...dynago.Attribute) dynago.Span {
+	span := &memorySpan{name: name, attributes: map[string]interface{}{}}
+	span.SetAttributes(attributes...)
+	t.spans = append(t.spans, span)
+	return span
+}
+
+func (s *memorySpan) SetAttributes(attributes ...dynago.Attribute) {
+	for _, a := range attributes {
+		s.attributes[a.Key] = a.Value
+	}
+}
+
+func (s *memorySpan) RecordError(err error) { s.errors = append(s.errors, err) }
+func (s *memorySpan) End()                  { s.ended = true }
+
+type measurement struct {
+	value      float64
+	attributes []dynago.Attribute
+}
+
+type memoryHistogram struct {
+	measurements []measurement
+}
+
+func (h *memoryHistogram) Record(value float64, attributes ...dynago.Attribute) {
+	h.measurements = append(h.measurements, measurement{value, attributes})
+}
+
+func instrumentSetUp(t *testing.T) (*assert.Assertions, *dynago.Client, *dynago.MockExecutor, *memoryTracer, *memoryHistogram, *memoryHistogram) {
+	tracer, latency, capacity := &memoryTracer{}, &memoryHistogram{}, &memoryHistogram{}
+	executor := &dynago.MockExecutor{}
+	client := dynago.NewClient(executor, dynago.Instrument(dynago.Instrumentation{
+		Tracer:   tracer,
+		Latency:  latency,
+		Capacity: capacity,
+	}))
+	return assert.New(t), client, executor, tracer, latency, capacity
+}
+
+func TestInstrumentQuery(t *testing.T) {
+	assert, client, executor, tracer, latency, capacity := instrumentSetUp(t)
+	executor.QueryResult = &dynago.QueryResult{
+		Items:        []dynago.Document{{"Id": 1}, {"Id": 2}},
+		Count:        2,
+		ScannedCount: 5,
+		ConsumedCapacity: &dynago.ConsumedCapacity{
+			TableName:     "Users",
+			CapacityUnits: 2.5,
+		},
+	}
+	_, err := client.Query("Users").IndexName("ByName").Execute()
+	assert.NoError(err)
+
+	assert.Equal(1, len(tracer.spans))
+	span := tracer.spans[0]
+	assert.Equal("Query Users", span.name)
+	assert.True(span.ended)
+	assert.Equal("dynamodb", span.attributes[dynago.AttrDBSystem])
+	assert.Equal("Query", span.attributes[dynago.AttrDBOperation])
+	assert.Equal([]string{"Users"}, span.attributes[dynago.AttrTableNames])
+	assert.Equal("ByName", span.attributes[dynago.AttrIndexName])
+	assert.Equal(2, span.attributes[dynago.AttrCount])
+	assert.Equal(5, span.attributes[dynago.AttrScannedCount])
+	assert.Contains(span.attributes[dynago.AttrConsumedCapacity].([]string)[0], `"CapacityUnits":2.5`)
+	assert.Nil(span.attributes[dynago.AttrErrorType])
+
+	assert.Equal(1, len(latency.measurements))
+	assert.Equal([]dynago.Attribute{
+		{Key: dynago.AttrDBSystem, Value: "dynamodb"},
+		{Key: dynago.AttrDBOperation, Value: "Query"},
+		{Key: dynago.AttrTableNames, Value: []string{"Users"}},
+	}, latency.measurements[0].attributes)
+
+	assert.Equal(1, len(capacity.measurements))
+	assert.Equal(2.5, capacity.measurements[0].value)
+}
+
+func TestInstrumentError(t *testing.T) {
+	assert, client, executor, tracer, latency, capacity := instrumentSetUp(t)
+	executor.GetItemError = &dynago.Error{Type: dynago.ErrorThroughputExceeded}
+	_, err := client.GetItem("Users", dynago.HashKey("Id", 1)).Execute()
+	assert.Error(err)
+
+	span := tracer.spans[0]
+	assert.Equal("GetItem Users", span.name)
+	assert.Equal([]error{err}, span.errors)
+	assert.Equal("ErrorThroughputExceeded", span.attributes[dynago.AttrErrorType])
+	assert.Nil(span.attributes[dynago.AttrIndexName])
+	assert.Equal(dynago.Attribute{Key: dynago.AttrErrorType, Value: "ErrorThroughputExceeded"}, latency.measurements[0].attributes[3])
+	assert.Equal(0, len(capacity.measurements))
+}
+
+func TestInstrumentPartial(t *testing.T) {
+	assert := assert.New(t)
+	latency := &memoryHistogram{}
+	client := dynago.NewClient(&dynago.MockExecutor{}, dynago.Instrument(dynago.Instrumentation{Latency: latency}))
+	client.Scan("Users").Execute()
+	client.BatchGet().Get("Users", dynago.HashKey("Id", 1)).Execute()
+	assert.Equal(2, len(latency.measurements))
+	assert.Equal(dynago.Attribute{Key: dynago.AttrDBOperation, Value: "BatchGetItem"}, latency.measurements[1].attributes[1])
+}
+
+func TestInstrumentRetries(t *testing.T) {
+	assert := assert.New(t)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"__type": "com.amazonaws.dynamodb.v20120810#InternalServerError"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	tracer, retries := &memoryTracer{}, &memoryHistogram{}
+	executor := dynago.NewAwsExecutorWithOptions(dynago.AwsExecutorOptions{Region: "us-east-1", Endpoint: server.URL, MaxRetries: 3})
+	client := dynago.NewClient(executor, dynago.Instrument(dynago.Instrumentation{Tracer: tracer, Retries: retries}))
+
+	_, err := client.GetItem("Users", dynago.HashKey("Id", 1)).Execute()
+	assert.NoError(err)
+	assert.Equal(2, tracer.spans[0].attributes[dynago.AttrResendCount])
+	assert.Equal(2.0, retries.measurements[0].value)
+
+	_, err = client.GetItem("Users", dynago.HashKey("Id", 1)).Execute()
+	assert.NoError(err)
+	assert.Nil(tracer.spans[1].attributes[dynago.AttrResendCount])
+	assert.Equal(0.0, retries.measurements[1].value)
+}
+
+func TestInstrumentRetriesWrapped(t *testing.T) {
+	assert := assert.New(t)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls%3 != 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"__type": "com.amazonaws.dynamodb.v20120810#InternalServerError"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	tracer := &memoryTracer{}
+	executor := dynago.NewAwsExecutorWithOptions(dynago.AwsExecutorOptions{Region: "us-east-1", Endpoint: server.URL, MaxRetries: 3})
+	recorder := &dynago.Recorder{Requester: executor.Requester}
+	executor.Requester = recorder
+	faults := &dynago.FaultInjector{Executor: executor, TruncateRate: 1}
+	client := dynago.NewClient(faults, dynago.Instrument(dynago.Instrumentation{Tracer: tracer}))
+
+	_, err := client.GetItem("Users", dynago.HashKey("Id", 1)).Execute()
+	assert.NoError(err)
+	assert.Equal(2, tracer.spans[0].attributes[dynago.AttrResendCount])
+
+	// Truncated pages are counted too.
+	_, err = client.Query("Users").KeyConditionExpression("Id = :id").Param(":id", 1).Execute()
+	assert.NoError(err)
+	assert.Equal(2, tracer.spans[1].attributes[dynago.AttrResendCount])
+	assert.Equal(2, len(recorder.Cassette().Interactions))
+}
@@ -2,21 +2,40 @@ package aws
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"hash/crc32"
 	"io"
+	"log/slog"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // DynamoTargetPrefix is the Dynamo API version we support.
 const DynamoTargetPrefix = "DynamoDB_20120810."
 
-// MaxResponseSize is the maximum size of a response.
-var MaxResponseSize int64 = 25 * 1024 * 1024 // 25MB maximum response
+// DefaultMaxResponseSize is the maximum size of a response if RequestMaker.MaxResponseSize is not set.
+const DefaultMaxResponseSize int64 = 25 * 1024 * 1024 // 25MB maximum response
+
+// Backoff between retries starts at retryBaseDelay and doubles up to retryMaxDelay.
+const (
+	retryBaseDelay = 50 * time.Millisecond
+	retryMaxDelay  = 5 * time.Second
+)
 
 // ErrMaxResponse is returned when responses are too big.
-var ErrMaxResponse = errors.New("Exceeded maximum response size of 25MB")
+var ErrMaxResponse = errors.New("Exceeded maximum response size")
+
+// ErrChecksumMismatch is returned when a response body doesn't match its x-amz-crc32 header.
+var ErrChecksumMismatch = errors.New("response body does not match x-amz-crc32 checksum")
+
+// ErrTruncatedResponse is returned when a response body is shorter than its Content-Length.
+var ErrTruncatedResponse = errors.New("response body was truncated")
 
 // A Signer's job is to perform API signing.
 type Signer interface {
@@ -38,34 +57,98 @@ type RequestMaker struct {
 	BuildError func(*http.Request, []byte, *http.Response, []byte) error
 
 	// These can be optionally set
-	Caller         http.Client
-	DebugRequests  bool
-	DebugResponses bool
-	DebugFunc      func(string, ...interface{})
+	WrapTransportError func(*http.Request, error) error // Wraps errors from making the HTTP request
+	MaxResponseSize    int64                            // Defaults to DefaultMaxResponseSize
+	Caller             http.Client
+	AttemptTimeout     time.Duration    // Limits each HTTP request, including reading the body
+	TotalTimeout       time.Duration    // Limits a whole MakeRequest call, including retries
+	MaxRetries         int              // Retries after the first attempt fails
+	ShouldRetry        func(error) bool // Decides if an error is retried; required if MaxRetries is set
+	Logger             *slog.Logger     // Logs each attempt at debug level, or warn if it failed
+	LogBodies          bool             // Include request and response bodies in Logger output
+	RedactValues       bool             // Replace attribute values in logged bodies
+	DebugRequests      bool
+	DebugResponses     bool
+	DebugFunc          func(string, ...interface{})
 }
 
+/*
+MakeRequest makes a request to target, retrying up to MaxRetries times on
+errors accepted by ShouldRetry.
+
+Retries back off exponentially with jitter, and stop early if the next attempt
+could not start before TotalTimeout.
+*/
 func (r *RequestMaker) MakeRequest(target string, body []byte) ([]byte, error) {
-	req, err := http.NewRequest("POST", r.Endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, err
-	}
+	respBody, _, err := r.MakeRequestAttempts(target, body)
+	return respBody, err
+}
+
+// MakeRequestAttempts is like MakeRequest, but also gives the number of attempts made, including retries.
+func (r *RequestMaker) MakeRequestAttempts(target string, body []byte) ([]byte, int, error) {
 	if !strings.Contains(target, ".") {
 		target = DynamoTargetPrefix + target
 	}
+	ctx := context.Background()
+	if r.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.TotalTimeout)
+		defer cancel()
+	}
+	for attempt := 0; ; attempt++ {
+		respBody, err := r.attempt(ctx, target, body, attempt)
+		if err == nil || attempt >= r.MaxRetries || r.ShouldRetry == nil || !r.ShouldRetry(err) {
+			return respBody, attempt + 1, err
+		}
+		delay := retryBaseDelay << uint(attempt)
+		if delay <= 0 || delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+		delay = time.Duration(rand.Int63n(int64(delay)) + 1)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return respBody, attempt + 1, err
+		}
+		time.Sleep(delay)
+	}
+}
+
+func (r *RequestMaker) attempt(ctx context.Context, target string, body []byte, attempt int) (respBody []byte, err error) {
+	if r.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.AttemptTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", r.Endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Add("x-amz-target", target)
 	req.Header.Add("content-type", "application/x-amz-json-1.0")
 	req.Header.Set("Host", req.URL.Host)
 	r.Signer.SignRequest(req, body)
 	if r.DebugRequests {
-		r.DebugFunc("Request:%#v\n\nRequest Body: %s\n\n", req, body)
+		r.DebugFunc("Request:%#v\n\nRequest Body: %s\n\n", redactRequest(req), r.redactBody(body))
+	}
+	start := time.Now()
+	var response *http.Response
+	if r.Logger != nil {
+		defer func() {
+			r.logAttempt(ctx, target, attempt, body, response, respBody, time.Since(start), err)
+		}()
 	}
-	response, err := r.Caller.Do(req)
+	response, err = r.Caller.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, r.wrapTransportError(req, err)
 	}
-	respBody, err := responseBytes(response)
+	respBody, err = r.responseBytes(response)
 	if r.DebugResponses {
-		r.DebugFunc("Response: %#v\nBody:%s\n", response, respBody)
+		r.DebugFunc("Response: %#v\nBody:%s\n", response, r.redactBody(respBody))
+	}
+	if err != nil {
+		if err != ErrMaxResponse {
+			err = r.wrapTransportError(req, err)
+		}
+		return nil, err
 	}
 	if response.StatusCode != http.StatusOK {
 		err = r.BuildError(req, body, response, respBody)
@@ -73,23 +156,51 @@ func (r *RequestMaker) MakeRequest(target string, body []byte) ([]byte, error) {
 	return respBody, err
 }
 
-func responseBytes(response *http.Response) (output []byte, err error) {
+func (r *RequestMaker) wrapTransportError(req *http.Request, err error) error {
+	if r.WrapTransportError != nil {
+		return r.WrapTransportError(req, err)
+	}
+	return err
+}
+
+/*
+Read the response body, checking it arrived intact.
+
+A body shorter than the Content-Length gives ErrTruncatedResponse, and a body
+not matching the x-amz-crc32 header DynamoDB sends gives ErrChecksumMismatch.
+The checksum is of the bytes on the wire, so it can't be checked if net/http
+transparently decompressed the body.
+*/
+func (r *RequestMaker) responseBytes(response *http.Response) (output []byte, err error) {
+	defer response.Body.Close()
+	maxSize := r.MaxResponseSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxResponseSize
+	}
 	if response.ContentLength != 0 {
 		var buffer bytes.Buffer
-		reader := io.LimitReader(response.Body, MaxResponseSize)
-		if response.ContentLength > 0 {
+		reader := io.LimitReader(response.Body, maxSize)
+		if response.ContentLength > 0 && response.ContentLength < maxSize {
 			buffer.Grow(int(response.ContentLength)) // avoid a ton of allocations
 		}
 		var n int64
 		n, err = io.Copy(&buffer, reader)
-		if n >= MaxResponseSize {
-			err = ErrMaxResponse
-		} else if err == nil {
-			output = buffer.Bytes()
-			err = response.Body.Close()
+		if n >= maxSize {
+			return nil, ErrMaxResponse
+		} else if err == io.ErrUnexpectedEOF || (err == nil && response.ContentLength > 0 && n < response.ContentLength) {
+			return nil, ErrTruncatedResponse
+		} else if err != nil {
+			return nil, err
+		}
+		output = buffer.Bytes()
+	}
+	if header := response.Header.Get("x-amz-crc32"); header != "" && !response.Uncompressed {
+		expected, err := strconv.ParseUint(header, 10, 32)
+		if err != nil || uint32(expected) != crc32.ChecksumIEEE(output) {
+			return nil, fmt.Errorf("%w (header %s, body %d)", ErrChecksumMismatch, header, crc32.ChecksumIEEE(output))
 		}
 	}
-	return
+	return output, nil
 }
 
 func FixEndpointUrl(endpoint string) string {
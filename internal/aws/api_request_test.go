@@ -1,6 +1,15 @@
 package aws
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,3 +22,163 @@ func TestFixEndpointUrl(t *testing.T) {
 	assert.Equal("https://dynamodb.fake.com:443/", FixEndpointUrl("https://dynamodb.fake.com"))
 	assert.Equal("https://dynamodb.fake.com:443/foo", FixEndpointUrl("https://dynamodb.fake.com/foo"))
 }
+
+type nopSigner struct{}
+
+func (nopSigner) SignRequest(*http.Request, []byte) {}
+
+func requestMakerSetUp(t *testing.T, handler http.HandlerFunc) (*assert.Assertions, *RequestMaker) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	maker := &RequestMaker{
+		Endpoint: FixEndpointUrl(server.URL),
+		Signer:   nopSigner{},
+		BuildError: func(*http.Request, []byte, *http.Response, []byte) error {
+			return errors.New("service error")
+		},
+		WrapTransportError: func(req *http.Request, err error) error {
+			return fmt.Errorf("transport: %w", err)
+		},
+	}
+	return assert.New(t), maker
+}
+
+func TestResponseChecksum(t *testing.T) {
+	body := `{"Item": {}}`
+	checksum := fmt.Sprint(crc32.ChecksumIEEE([]byte(body)))
+	assert, maker := requestMakerSetUp(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-amz-crc32", r.Header.Get("X-Test-Checksum"))
+		w.Write([]byte(body))
+	})
+	call := func(header string) ([]byte, error) {
+		maker.Signer = headerSigner(header)
+		return maker.MakeRequest("GetItem", nil)
+	}
+
+	result, err := call(checksum)
+	assert.NoError(err)
+	assert.Equal(body, string(result))
+
+	result, err = call("12345")
+	assert.Nil(result)
+	assert.True(errors.Is(err, ErrChecksumMismatch))
+	assert.True(strings.HasPrefix(err.Error(), "transport: "))
+
+	_, err = call("bogus")
+	assert.True(errors.Is(err, ErrChecksumMismatch))
+
+	// No header, nothing to check
+	_, err = call("")
+	assert.NoError(err)
+}
+
+// headerSigner passes the wanted checksum through to the test server.
+type headerSigner string
+
+func (s headerSigner) SignRequest(req *http.Request, body []byte) {
+	req.Header.Set("X-Test-Checksum", string(s))
+}
+
+func TestResponseTruncated(t *testing.T) {
+	assert, maker := requestMakerSetUp(t, func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, _ := w.(http.Hijacker).Hijack()
+		buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 100\r\nContent-Type: application/x-amz-json-1.0\r\n\r\n{\"Item\":")
+		buf.Flush()
+		conn.Close()
+	})
+	result, err := maker.MakeRequest("GetItem", nil)
+	assert.Nil(result)
+	assert.True(errors.Is(err, ErrTruncatedResponse))
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	assert, maker := requestMakerSetUp(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 100)))
+	})
+	result, err := maker.MakeRequest("GetItem", nil)
+	assert.NoError(err)
+	assert.Equal(100, len(result))
+
+	maker.MaxResponseSize = 50
+	result, err = maker.MakeRequest("GetItem", nil)
+	assert.Nil(result)
+	assert.Equal(ErrMaxResponse, err)
+}
+
+func TestRequestLogging(t *testing.T) {
+	var logs bytes.Buffer
+	assert, maker := requestMakerSetUp(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-amzn-RequestId", "REQ1")
+		w.Write([]byte(`{"Item": {"Name": {"S": "Bob"}}}`))
+	})
+	maker.Signer = &AwsSigner{AccessKey: "AKID", SecretKey: "SECRET", Region: "us-east-1", Service: "dynamodb"}
+	maker.Logger = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	maker.LogBodies = true
+	maker.RedactValues = true
+
+	_, err := maker.MakeRequest("GetItem", []byte(`{"TableName": "People", "Key": {"Id": {"N": "8675309"}}}`))
+	assert.NoError(err)
+	var record map[string]interface{}
+	assert.NoError(json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal("DEBUG", record["level"])
+	assert.Equal("DynamoDB_20120810.GetItem", record["target"])
+	assert.Equal("People", record["table"])
+	assert.Equal(float64(200), record["status"])
+	assert.Equal("REQ1", record["request_id"])
+	assert.Equal(float64(1), record["attempt"])
+	assert.Equal(`{"Key":{"Id":{"N":"REDACTED"}},"TableName":"People"}`, record["request_body"])
+	assert.Equal(`{"Item":{"Name":{"S":"REDACTED"}}}`, record["response_body"])
+	assert.NotContains(logs.String(), "AKID")
+	assert.NotContains(logs.String(), "8675309")
+}
+
+func TestRedactValues(t *testing.T) {
+	assert := assert.New(t)
+	maker := &RequestMaker{RedactValues: true}
+	input := `{
+		"ExpressionAttributeNames": {"#n": "Name"},
+		"ExpressionAttributeValues": {":v": {"SS": ["a", "b"]}, ":nil": {"NULL": true}},
+		"Item": {
+			"Address": {"M": {"City": {"S": "Boston"}}},
+			"Tags": {"L": [{"S": "x"}, {"N": "5"}]}
+		}
+	}`
+	expected := `{"ExpressionAttributeNames":{"#n":"Name"},` +
+		`"ExpressionAttributeValues":{":nil":{"NULL":true},":v":{"SS":"REDACTED"}},` +
+		`"Item":{"Address":{"M":{"City":{"S":"REDACTED"}}},"Tags":{"L":[{"S":"REDACTED"},{"N":"REDACTED"}]}}}`
+	assert.Equal(expected, string(maker.redactBody([]byte(input))))
+	assert.Equal("REDACTED", string(maker.redactBody([]byte("not json"))))
+
+	maker.RedactValues = false
+	assert.Equal(input, string(maker.redactBody([]byte(input))))
+}
+
+func TestRedactRequest(t *testing.T) {
+	assert := assert.New(t)
+	req, _ := http.NewRequest("POST", "http://localhost/", nil)
+	(&AwsSigner{AccessKey: "AKID", SecretKey: "SECRET", Region: "us-east-1", Service: "dynamodb"}).SignRequest(req, nil)
+	assert.Contains(req.Header.Get("Authorization"), "AKID")
+	assert.Equal("REDACTED", redactRequest(req).Header.Get("Authorization"))
+	assert.Contains(req.Header.Get("Authorization"), "AKID")
+}
+
+func TestSignerLogging(t *testing.T) {
+	assert := assert.New(t)
+	var logs bytes.Buffer
+	signer := &AwsSigner{AccessKey: "AKID", SecretKey: "SECRET", Region: "us-east-1", Service: "dynamodb"}
+	signer.Logger = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	var debugged string
+	signer.DebugFunc = func(format string, v ...interface{}) { debugged = fmt.Sprintf(format, v...) }
+
+	req, _ := http.NewRequest("POST", "http://localhost/", nil)
+	req.Header.Set("x-amz-target", "DynamoDB_20120810.GetItem")
+	signer.SignRequest(req, []byte("{}"))
+
+	var record map[string]string
+	json.Unmarshal(logs.Bytes(), &record)
+	assert.True(strings.HasPrefix(record["canonical_request"], "POST\n/\n\n"))
+	assert.Contains(record["canonical_request"], "x-amz-target:DynamoDB_20120810.GetItem\n")
+	assert.True(strings.HasPrefix(record["string_to_sign"], "AWS4-HMAC-SHA256\n"))
+	assert.NotContains(logs.String(), "SECRET")
+	assert.Contains(debugged, record["string_to_sign"])
+}
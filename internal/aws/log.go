@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// Headers carrying credentials, which are never logged.
+var credentialHeaders = []string{"Authorization", "X-Amz-Security-Token"}
+
+// The type keys of DynamoDB attribute values, e.g. {"S": "foo"}.
+var attributeTypes = map[string]bool{
+	"S": true, "N": true, "B": true, "BOOL": true, "NULL": true,
+	"SS": true, "NS": true, "BS": true, "L": true, "M": true,
+}
+
+// Log one attempt at a request to the structured logger.
+func (r *RequestMaker) logAttempt(ctx context.Context, target string, attempt int, body []byte, response *http.Response, respBody []byte, elapsed time.Duration, err error) {
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	if !r.Logger.Enabled(ctx, level) {
+		return
+	}
+	var req struct{ TableName string }
+	json.Unmarshal(body, &req)
+	attrs := []slog.Attr{
+		slog.String("target", target),
+		slog.String("table", req.TableName),
+		slog.Int("attempt", attempt+1),
+		slog.Duration("latency", elapsed),
+		slog.Int("request_bytes", len(body)),
+	}
+	if response != nil {
+		attrs = append(attrs,
+			slog.Int("status", response.StatusCode),
+			slog.String("request_id", response.Header.Get("x-amzn-RequestId")),
+			slog.Int("response_bytes", len(respBody)),
+		)
+	}
+	if r.LogBodies {
+		attrs = append(attrs, slog.String("request_body", string(r.redactBody(body))))
+		if respBody != nil {
+			attrs = append(attrs, slog.String("response_body", string(r.redactBody(respBody))))
+		}
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	r.Logger.LogAttrs(ctx, level, "dynago: request", attrs...)
+}
+
+func (r *RequestMaker) redactBody(body []byte) []byte {
+	if !r.RedactValues {
+		return body
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return []byte(redacted)
+	}
+	output, _ := json.Marshal(redactValues(decoded))
+	return output
+}
+
+/*
+Replace the contents of all attribute values in a decoded DynamoDB request or
+response, keeping attribute names and the rest of the structure.
+
+Anything that looks like an attribute value is redacted, so an item with a
+single attribute named e.g. "S" is redacted a little more eagerly than needed.
+*/
+func redactValues(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 1 {
+			for kind, inner := range v {
+				if attributeTypes[kind] {
+					return map[string]interface{}{kind: redactAttribute(kind, inner)}
+				}
+			}
+		}
+		for key, inner := range v {
+			v[key] = redactValues(inner)
+		}
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = redactValues(inner)
+		}
+	}
+	return v
+}
+
+func redactAttribute(kind string, v interface{}) interface{} {
+	switch kind {
+	case "M", "L":
+		return redactValues(v)
+	case "NULL":
+		return v
+	}
+	return redacted
+}
+
+// A copy of req with credentials removed, for debug output.
+func redactRequest(req *http.Request) *http.Request {
+	clone := req.Clone(req.Context())
+	for _, name := range credentialHeaders {
+		if clone.Header.Get(name) != "" {
+			clone.Header.Set(name, redacted)
+		}
+	}
+	return clone
+}
@@ -9,9 +9,11 @@ package aws
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"log/slog"
 	"net/http"
 	"sort"
 	"strings"
@@ -34,22 +36,37 @@ type AwsSigner struct {
 	SecretKey string
 	Region    string
 	Service   string
+
+	// If set, the canonical request and string to sign are logged for each
+	// request, to help debug signature mismatches. Neither contains secrets.
+	Logger    *slog.Logger
+	DebugFunc func(string, ...interface{})
 }
 
 func (info *AwsSigner) SignRequest(request *http.Request, bodyBytes []byte) {
 	now := time.Now().UTC()
 	isoDateSmash := now.Format("20060102T150405Z")
 	request.Header.Add("x-amz-date", isoDateSmash)
-	canonicalHash, signedHeaders := canonicalRequest(request, bodyBytes)
+	canonical, signedHeaders := canonicalRequest(request, bodyBytes)
+	sum := sha256.Sum256(canonical)
 	credentialScope := now.Format("20060102") + "/" + info.Region + "/" + info.Service + "/aws4_request"
-	stringToSign := algorithm + "\n" + isoDateSmash + "\n" + credentialScope + "\n" + canonicalHash
+	stringToSign := algorithm + "\n" + isoDateSmash + "\n" + credentialScope + "\n" + hex.EncodeToString(sum[:])
+	if info.Logger != nil {
+		info.Logger.LogAttrs(context.Background(), slog.LevelDebug, "dynago: signing request",
+			slog.String("canonical_request", string(canonical)),
+			slog.String("string_to_sign", stringToSign),
+		)
+	}
+	if info.DebugFunc != nil {
+		info.DebugFunc("Canonical Request:\n%s\n\nString To Sign:\n%s\n\n", canonical, stringToSign)
+	}
 	signingKey := signingKey(now, info)
 	signature := hex.EncodeToString(hmacShort(signingKey, []byte(stringToSign)))
 	authHeader := algorithm + " Credential=" + info.AccessKey + "/" + credentialScope + ", SignedHeaders=" + signedHeaders + ", Signature=" + signature
 	request.Header.Add("Authorization", authHeader)
 }
 
-func canonicalRequest(request *http.Request, bodyBytes []byte) (string, string) {
+func canonicalRequest(request *http.Request, bodyBytes []byte) ([]byte, string) {
 	var canonical bytes.Buffer
 	canonical.WriteString(request.Method)
 	canonical.WriteByte('\n')
@@ -60,9 +77,7 @@ func canonicalRequest(request *http.Request, bodyBytes []byte) (string, string)
 	signedHeaders := canonicalHeaders(&canonical, request.Header)
 	sum := sha256.Sum256(bodyBytes)
 	canonical.WriteString(hex.EncodeToString(sum[:]))
-	cBytes := canonical.Bytes()
-	sum = sha256.Sum256(cBytes)
-	return hex.EncodeToString(sum[:]), signedHeaders
+	return canonical.Bytes(), signedHeaders
 }
 
 func canonicalHeaders(buf *bytes.Buffer, headers http.Header) string {
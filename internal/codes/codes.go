@@ -7,6 +7,11 @@ package codes
 
 type ErrorCode int
 
+// Error lets each ErrorCode be used as a sentinel error, e.g. with errors.Is.
+func (c ErrorCode) Error() string {
+	return "dynago: " + c.String()
+}
+
 const (
 	ErrorUnknown ErrorCode = iota
 
@@ -24,4 +29,15 @@ const (
 	// DynamoDB Streams
 	ErrorExpiredIterator // Iterator is no longer valid
 	ErrorTrimmedData     // Attempted to access data older than 24h
+
+	// Transactions
+	ErrorTransactionCanceled   // Transaction canceled; see CancellationReasons for why
+	ErrorTransactionConflict   // Another transaction is modifying the item
+	ErrorTransactionInProgress // Transaction with this client token is still being processed
+
+	ErrorRequestLimitExceeded         // Account-level request rate limits exceeded, try later
+	ErrorIdempotentParameterMismatch  // Client token reused with different request parameters
+	ErrorBackupInUse                  // Backup is being created, deleted or restored
+	ErrorContinuousBackupsUnavailable // Continuous backups or point in time recovery not available
+	ErrorDuplicateItem                // PartiQL insert of an item that already exists
 )
@@ -32,4 +32,19 @@ func TestCodeMatch(t *testing.T) {
 
 	check(dynago.ErrorExpiredIterator, codes.ErrorExpiredIterator)
 	check(dynago.ErrorTrimmedData, codes.ErrorTrimmedData)
+
+	check(dynago.ErrorTransactionCanceled, codes.ErrorTransactionCanceled)
+	check(dynago.ErrorTransactionConflict, codes.ErrorTransactionConflict)
+	check(dynago.ErrorTransactionInProgress, codes.ErrorTransactionInProgress)
+	check(dynago.ErrorRequestLimitExceeded, codes.ErrorRequestLimitExceeded)
+	check(dynago.ErrorIdempotentParameterMismatch, codes.ErrorIdempotentParameterMismatch)
+	check(dynago.ErrorBackupInUse, codes.ErrorBackupInUse)
+	check(dynago.ErrorContinuousBackupsUnavailable, codes.ErrorContinuousBackupsUnavailable)
+	check(dynago.ErrorDuplicateItem, codes.ErrorDuplicateItem)
+}
+
+func TestCodeStrings(t *testing.T) {
+	assert.Equal(t, "ErrorTrimmedData", codes.ErrorTrimmedData.String())
+	assert.Equal(t, "ErrorDuplicateItem", codes.ErrorDuplicateItem.String())
+	assert.Equal(t, "ErrorCode(99)", codes.ErrorCode(99).String())
 }
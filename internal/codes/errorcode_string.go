@@ -4,9 +4,9 @@ package codes
 
 import "fmt"
 
-const _ErrorCode_name = "ErrorUnknownErrorConditionFailedErrorCollectionSizeExceededErrorThroughputExceededErrorNotFoundErrorInternalFailureErrorAuthErrorInvalidParameterErrorServiceUnavailableErrorThrottlingErrorResourceInUseErrorExpiredIteratorErrorTrimmedData"
+const _ErrorCode_name = "ErrorUnknownErrorConditionFailedErrorCollectionSizeExceededErrorThroughputExceededErrorNotFoundErrorInternalFailureErrorAuthErrorInvalidParameterErrorServiceUnavailableErrorThrottlingErrorResourceInUseErrorExpiredIteratorErrorTrimmedDataErrorTransactionCanceledErrorTransactionConflictErrorTransactionInProgressErrorRequestLimitExceededErrorIdempotentParameterMismatchErrorBackupInUseErrorContinuousBackupsUnavailableErrorDuplicateItem"
 
-var _ErrorCode_index = [...]uint8{0, 12, 32, 59, 82, 95, 115, 124, 145, 168, 183, 201, 221, 237}
+var _ErrorCode_index = [...]uint16{0, 12, 32, 59, 82, 95, 115, 124, 145, 168, 183, 201, 221, 237, 261, 285, 311, 336, 368, 384, 417, 435}
 
 func (i ErrorCode) String() string {
 	if i < 0 || i >= ErrorCode(len(_ErrorCode_index)-1) {
@@ -15,7 +15,9 @@ type ErrorConfig struct {
 
 // This variable is mostly exposed so that we can document how errors are mapped
 var MappedErrors = []ErrorConfig{
+	{"AccessDeniedException", 400, codes.ErrorAuth},
 	{"ConditionalCheckFailedException", 400, codes.ErrorConditionFailed},
+	{"ExpiredTokenException", 400, codes.ErrorAuth},
 	{"InternalFailure", 500, codes.ErrorInternalFailure},
 	{"InternalServerError", 500, codes.ErrorInternalFailure},
 	{"IncompleteSignature", 400, codes.ErrorAuth},
@@ -24,6 +26,7 @@ var MappedErrors = []ErrorConfig{
 	{"InvalidParameterValue", 400, codes.ErrorInvalidParameter},
 	{"InvalidQueryParameter", 400, codes.ErrorInvalidParameter},
 	{"InvalidSignatureException", 400, codes.ErrorAuth},
+	{"ItemCollectionSizeLimitExceeded", 400, codes.ErrorCollectionSizeExceeded},
 	{"ItemCollectionSizeLimitExceededException", 400, codes.ErrorCollectionSizeExceeded},
 	{"MalformedQueryString", 404, codes.ErrorInvalidParameter},
 	{"MissingAction", 400, codes.ErrorInvalidParameter},
@@ -32,15 +35,40 @@ var MappedErrors = []ErrorConfig{
 	{"OptInRequired", 403, codes.ErrorAuth},
 	{"ProvisionedThroughputExceededException", 400, codes.ErrorThroughputExceeded},
 	{"RequestExpired", 400, codes.ErrorAuth},
+	{"RequestLimitExceeded", 400, codes.ErrorRequestLimitExceeded},
 	{"ResourceInUseException", 400, codes.ErrorResourceInUse},
 	{"ResourceNotFoundException", 400, codes.ErrorNotFound},
+	{"SerializationException", 400, codes.ErrorInvalidParameter},
 	{"ServiceUnavailable", 503, codes.ErrorServiceUnavailable},
 	{"ServiceUnavailableException", 503, codes.ErrorServiceUnavailable},
 	{"ThrottlingException", 400, codes.ErrorThrottling},
+	{"UnknownOperationException", 400, codes.ErrorInvalidParameter},
 	{"UnrecognizedClientException", 400, codes.ErrorAuth},
 	{"ValidationError", 400, codes.ErrorInvalidParameter},
 	{"ValidationException", 400, codes.ErrorInvalidParameter},
 
+	// Transactions
+	{"DuplicateItemException", 400, codes.ErrorDuplicateItem},
+	{"IdempotentParameterMismatchException", 400, codes.ErrorIdempotentParameterMismatch},
+	{"TransactionCanceledException", 400, codes.ErrorTransactionCanceled},
+	{"TransactionConflictException", 400, codes.ErrorTransactionConflict},
+	{"TransactionInProgressException", 400, codes.ErrorTransactionInProgress},
+
+	// Tables, indexes, backups and global tables
+	{"BackupInUseException", 400, codes.ErrorBackupInUse},
+	{"BackupNotFoundException", 400, codes.ErrorNotFound},
+	{"ContinuousBackupsUnavailableException", 400, codes.ErrorContinuousBackupsUnavailable},
+	{"GlobalTableAlreadyExistsException", 400, codes.ErrorResourceInUse},
+	{"GlobalTableNotFoundException", 400, codes.ErrorNotFound},
+	{"IndexNotFoundException", 400, codes.ErrorNotFound},
+	{"InvalidRestoreTimeException", 400, codes.ErrorInvalidParameter},
+	{"PointInTimeRecoveryUnavailableException", 400, codes.ErrorContinuousBackupsUnavailable},
+	{"ReplicaAlreadyExistsException", 400, codes.ErrorResourceInUse},
+	{"ReplicaNotFoundException", 400, codes.ErrorNotFound},
+	{"TableAlreadyExistsException", 400, codes.ErrorResourceInUse},
+	{"TableInUseException", 400, codes.ErrorResourceInUse},
+	{"TableNotFoundException", 400, codes.ErrorNotFound},
+
 	// DynamoDB Streams
 	{"ExpiredIteratorException", 400, codes.ErrorExpiredIterator},
 	{"LimitExceededException", 400, codes.ErrorThrottling},
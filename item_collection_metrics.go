@@ -0,0 +1,21 @@
+package dynago
+
+/*
+ItemCollectionMetrics describes the size of an item collection: the items
+sharing a hash key in a table with local secondary indexes.
+
+Item collections are limited to 10GB, so these can be monitored to see when a
+hash key is getting close to the limit. They are only returned when
+ReturnItemCollectionMetrics is set to ItemCollectionMetricsSize, and only for
+tables with local secondary indexes.
+*/
+type ItemCollectionMetrics struct {
+	// The hash key of the item collection.
+	ItemCollectionKey Document
+
+	// Lower and upper bound of the estimated size of the collection in GB.
+	SizeEstimateRangeGB []float64
+}
+
+// BatchItemCollectionMetrics describes ItemCollectionMetrics for multiple tables in batch requests.
+type BatchItemCollectionMetrics map[string][]ItemCollectionMetrics // table name -> metrics
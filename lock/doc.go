@@ -0,0 +1,32 @@
+/*
+Package lock is a distributed lock service on top of a DynamoDB table.
+
+Each lock is an item in the table, keyed by the lock name, which records the
+owner of the lock and when its lease expires. Locks are acquired with
+conditional puts, kept alive by heartbeats which extend the lease, and
+released by deleting the item, only if it is still owned by the releaser. A
+lock whose lease has expired, because its owner crashed or lost contact, can be
+taken over by anyone.
+
+	locks := lock.NewClient(&lock.Config{
+		Client: client,
+		Table:  "Locks",
+	})
+
+	l, err := locks.Acquire(ctx, "nightly-report")
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	// Do the work, stopping if the lock is lost.
+	return generateReport(l.Context())
+
+The table needs a string hash key, named LockName unless Config.KeyAttribute
+says otherwise, and no range key.
+
+Leases are compared against the clocks of the processes using the locks, so
+those clocks should be kept in sync, and the lease duration should be much
+longer than the clock skew between them.
+*/
+package lock
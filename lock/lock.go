@@ -0,0 +1,312 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rmfarrell/dynago"
+)
+
+var (
+	// ErrLockHeld is returned by TryAcquire when someone else holds an unexpired lease on the lock.
+	ErrLockHeld = errors.New("lock: lock is held by another owner")
+
+	// ErrLockLost is the cause of a lock's context being canceled when the
+	// lock was taken by someone else, or its lease expired before it could be renewed.
+	ErrLockLost = errors.New("lock: lock was lost")
+
+	// ErrReleased is the cause of a lock's context being canceled by Release.
+	ErrReleased = errors.New("lock: lock was released")
+)
+
+// Attributes of lock items, other than the key.
+const (
+	attrOwner   = "Owner"
+	attrToken   = "RecordVersion" // Changes on every acquire, so an owner can't confuse two of its own leases
+	attrExpires = "Expires"       // Unix time in milliseconds
+	attrLease   = "LeaseDuration" // Milliseconds, for people looking at the table
+)
+
+// Config is configuration for a lock client.
+type Config struct {
+	Client *dynago.Client // Client used to reach the lock table
+	Table  string         // Name of the lock table
+
+	KeyAttribute string // Hash key of the lock table. Defaults to LockName.
+	Owner        string // Identifies this client in lock items. Defaults to a random ID.
+
+	LeaseDuration     time.Duration // How long a lock is held without a heartbeat. Defaults to 20s.
+	HeartbeatInterval time.Duration // How often leases are renewed. Defaults to a third of LeaseDuration.
+	RetryInterval     time.Duration // How often Acquire tries again for a held lock. Defaults to 1s.
+}
+
+// NewClient returns a new lock client from config.
+func NewClient(config *Config) *Client {
+	c := &Client{
+		client:    config.Client,
+		table:     config.Table,
+		key:       config.KeyAttribute,
+		owner:     config.Owner,
+		lease:     config.LeaseDuration,
+		heartbeat: config.HeartbeatInterval,
+		retry:     config.RetryInterval,
+	}
+	if c.key == "" {
+		c.key = "LockName"
+	}
+	if c.owner == "" {
+		c.owner = randomID()
+	}
+	if c.lease <= 0 {
+		c.lease = 20 * time.Second
+	}
+	if c.heartbeat <= 0 {
+		c.heartbeat = c.lease / 3
+	}
+	if c.retry <= 0 {
+		c.retry = time.Second
+	}
+	return c
+}
+
+/*
+Client acquires locks in a lock table.
+
+A Client can be used from multiple goroutines, and can hold any number of
+locks, but can't acquire a lock it already holds.
+*/
+type Client struct {
+	client    *dynago.Client
+	table     string
+	key       string
+	owner     string
+	lease     time.Duration
+	heartbeat time.Duration
+	retry     time.Duration
+
+	now   func() time.Time                     // Overridden in tests
+	after func(time.Duration) <-chan time.Time // Overridden in tests
+}
+
+// Owner gives the owner ID this client writes in the locks it holds.
+func (c *Client) Owner() string {
+	return c.owner
+}
+
+/*
+TryAcquire makes one attempt to acquire the named lock, failing with ErrLockHeld
+if anyone holds an unexpired lease on it.
+
+The lock's heartbeat keeps running until it is released or lost; ctx only
+bounds this attempt, though the lock's context keeps its values.
+*/
+func (c *Client) TryAcquire(ctx context.Context, name string) (*Lock, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	token := randomID()
+	start := c.currentTime()
+	_, err := c.client.PutItem(c.table, dynago.Document{
+		c.key:       name,
+		attrOwner:   c.owner,
+		attrToken:   token,
+		attrExpires: start.Add(c.lease).UnixMilli(),
+		attrLease:   c.lease.Milliseconds(),
+	}).ConditionExpression("attribute_not_exists(#key) OR #expires < :now",
+		dynago.P("#key", c.key), dynago.P("#expires", attrExpires), dynago.P(":now", start.UnixMilli()),
+	).Execute()
+	if errors.Is(err, dynago.ErrorConditionFailed) {
+		return nil, ErrLockHeld
+	} else if err != nil {
+		return nil, err
+	}
+
+	lockCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	l := &Lock{
+		client:   c,
+		name:     name,
+		token:    token,
+		ctx:      lockCtx,
+		cancel:   cancel,
+		deadline: start.Add(c.lease),
+		done:     make(chan struct{}),
+	}
+	go l.heartbeat()
+	return l, nil
+}
+
+// Acquire acquires the named lock, waiting for it to be released or expire if it is held.
+func (c *Client) Acquire(ctx context.Context, name string) (*Lock, error) {
+	for {
+		l, err := c.TryAcquire(ctx, name)
+		if err != ErrLockHeld {
+			return l, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.wait(c.retry):
+		}
+	}
+}
+
+func (c *Client) currentTime() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *Client) wait(d time.Duration) <-chan time.Time {
+	if c.after != nil {
+		return c.after(d)
+	}
+	return time.After(d)
+}
+
+/*
+Lock is a lock held by a Client.
+
+The lock is held until Release is called, or until it is lost, which cancels
+its Context. A lock is lost if its lease can't be renewed before it expires,
+or if someone else has taken it.
+*/
+type Lock struct {
+	client *Client
+	name   string
+	token  string
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	done   chan struct{} // Closed when the heartbeat stops
+
+	lock       sync.Mutex // Held while renewing or releasing
+	deadline   time.Time  // When the lease runs out, by our clock
+	released   bool
+	releaseErr error
+}
+
+// Name gives the name of the lock.
+func (l *Lock) Name() string {
+	return l.name
+}
+
+/*
+Context is canceled when the lock is lost or released. context.Cause gives
+ErrLockLost or ErrReleased respectively.
+
+Work done under the lock should stop when the context is done, since someone
+else may hold the lock after that.
+*/
+func (l *Lock) Context() context.Context {
+	return l.ctx
+}
+
+/*
+Renew extends the lease on the lock. This is done automatically by the
+heartbeat, so it's only needed to extend the lease right away.
+
+If the lock has been taken by someone else, or the lease expired before it
+could be renewed, the lock is lost and ErrLockLost is returned. Other errors
+leave the lock held for the rest of its lease.
+*/
+func (l *Lock) Renew() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.ctx.Err() != nil {
+		return context.Cause(l.ctx)
+	}
+	c := l.client
+	start := c.currentTime()
+	if !start.Before(l.deadline) {
+		l.cancel(ErrLockLost)
+		return ErrLockLost
+	}
+	_, err := c.client.UpdateItem(c.table, dynago.HashKey(c.key, l.name)).
+		UpdateExpression("SET #expires = :expires", dynago.P("#expires", attrExpires), dynago.P(":expires", start.Add(c.lease).UnixMilli())).
+		ConditionExpression("#owner = :owner AND #token = :token", l.ownerParams()...).
+		Execute()
+	if errors.Is(err, dynago.ErrorConditionFailed) {
+		l.cancel(ErrLockLost)
+		return ErrLockLost
+	} else if err != nil {
+		return err
+	}
+	l.deadline = start.Add(c.lease)
+	return nil
+}
+
+/*
+Release releases the lock, first canceling its context, and then deleting the
+lock item if it is still ours.
+
+ErrLockLost is returned if the lock was lost before it was released. Releasing
+a lock more than once returns the result of the first release.
+*/
+func (l *Lock) Release() error {
+	l.cancel(ErrReleased)
+	<-l.done
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if !l.released {
+		l.released = true
+		l.releaseErr = l.release()
+	}
+	return l.releaseErr
+}
+
+func (l *Lock) release() error {
+	if cause := context.Cause(l.ctx); cause != ErrReleased {
+		return cause
+	}
+	c := l.client
+	_, err := c.client.DeleteItem(c.table, dynago.HashKey(c.key, l.name)).
+		ConditionExpression("#owner = :owner AND #token = :token", l.ownerParams()...).
+		Execute()
+	if errors.Is(err, dynago.ErrorConditionFailed) {
+		return ErrLockLost
+	}
+	return err
+}
+
+// Renews the lease every heartbeat interval, and gives up on the lock when the
+// lease runs out, which can be before the next heartbeat if renewals failed.
+func (l *Lock) heartbeat() {
+	defer close(l.done)
+	for {
+		l.lock.Lock()
+		interval := l.deadline.Sub(l.client.currentTime())
+		l.lock.Unlock()
+		if interval > l.client.heartbeat {
+			interval = l.client.heartbeat
+		} else if interval < 0 {
+			interval = 0
+		}
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-l.client.wait(interval):
+		}
+		if l.Renew() == ErrLockLost {
+			return
+		}
+	}
+}
+
+func (l *Lock) ownerParams() []dynago.Params {
+	return []dynago.Params{
+		dynago.P("#owner", attrOwner), dynago.P(":owner", l.client.owner),
+		dynago.P("#token", attrToken), dynago.P(":token", l.token),
+	}
+}
+
+func randomID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
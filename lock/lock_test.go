@@ -0,0 +1,185 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rmfarrell/dynago"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	conditionFailed = &dynago.Error{Type: dynago.ErrorConditionFailed}
+	throttled       = &dynago.Error{Type: dynago.ErrorThrottling}
+	start           = time.Unix(1000, 0)
+)
+
+// testClock is the time as seen by a lock client, and controls its waits.
+type testClock struct {
+	lock  sync.Mutex
+	time  time.Time
+	waits chan time.Duration // Heartbeat waits, sent when the heartbeat is idle
+	ticks chan time.Time     // Ends a heartbeat wait
+}
+
+func (c *testClock) now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.time
+}
+
+func (c *testClock) set(t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.time = t
+}
+
+func (c *testClock) after(d time.Duration) <-chan time.Time {
+	if d == time.Second { // Acquire retries don't wait
+		ready := make(chan time.Time, 1)
+		ready <- c.now()
+		return ready
+	}
+	c.waits <- d
+	return c.ticks
+}
+
+func setUp(t *testing.T) (*assert.Assertions, *Client, *dynago.MockExecutor, *testClock) {
+	executor := &dynago.MockExecutor{}
+	clock := &testClock{time: start, waits: make(chan time.Duration, 10), ticks: make(chan time.Time)}
+	client := NewClient(&Config{
+		Client:        dynago.NewClient(executor),
+		Table:         "Locks",
+		Owner:         "worker-1",
+		LeaseDuration: 30 * time.Second,
+	})
+	client.now = clock.now
+	client.after = clock.after
+	return assert.New(t), client, executor, clock
+}
+
+func TestAcquireRelease(t *testing.T) {
+	assert, client, executor, clock := setUp(t)
+	executor.Expect("PutItem").Table("Locks")
+	executor.Expect("DeleteItem").Key(dynago.HashKey("LockName", "report"))
+
+	l, err := client.TryAcquire(context.Background(), "report")
+	assert.NoError(err)
+	assert.Equal("report", l.Name())
+	assert.NoError(l.Context().Err())
+	assert.Equal(10*time.Second, <-clock.waits)
+
+	call := executor.PutItemCall
+	token := call.Item["RecordVersion"]
+	assert.Equal(dynago.Document{
+		"LockName":      "report",
+		"Owner":         "worker-1",
+		"RecordVersion": token,
+		"Expires":       start.Add(30 * time.Second).UnixMilli(),
+		"LeaseDuration": int64(30000),
+	}, call.Item)
+	assert.Equal("attribute_not_exists(#key) OR #expires < :now", call.ConditionExpression)
+	assert.Equal(dynago.Document{":now": start.UnixMilli()}, call.ExpressionAttributeValues)
+
+	assert.NoError(l.Release())
+	assert.Equal(ErrReleased, context.Cause(l.Context()))
+	call = executor.DeleteItemCall
+	assert.Equal("#owner = :owner AND #token = :token", call.ConditionExpression)
+	assert.Equal(dynago.Document{":owner": "worker-1", ":token": token}, call.ExpressionAttributeValues)
+
+	// Releasing again doesn't delete again.
+	assert.NoError(l.Release())
+	assert.Equal(ErrReleased, l.Renew())
+	assert.NoError(executor.ExpectationsMet())
+}
+
+func TestAcquireHeld(t *testing.T) {
+	assert, client, executor, clock := setUp(t)
+	executor.Expect("PutItem").ReturnError(conditionFailed).Times(2)
+	executor.Expect("PutItem")
+
+	_, err := client.TryAcquire(context.Background(), "report")
+	assert.Equal(ErrLockHeld, err)
+
+	l, err := client.Acquire(context.Background(), "report")
+	assert.NoError(err)
+	<-clock.waits
+	assert.Equal(3, len(executor.Calls))
+	assert.NoError(executor.ExpectationsMet())
+
+	// Acquire gives up when the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	executor.Expect("PutItem").Key(dynago.HashKey("LockName", "other")).Match(func(*dynago.MockExecutorCall) bool {
+		cancel()
+		return true
+	}).ReturnError(conditionFailed).AnyTimes()
+	_, err = client.Acquire(ctx, "other")
+	assert.Equal(context.Canceled, err)
+
+	executor.Expect("PutItem").ReturnError(throttled)
+	_, err = client.TryAcquire(context.Background(), "third")
+	assert.True(errors.Is(err, dynago.ErrorThrottling))
+	l.cancel(nil)
+}
+
+func TestHeartbeat(t *testing.T) {
+	assert, client, executor, clock := setUp(t)
+	executor.Expect("PutItem")
+	executor.Expect("UpdateItem").Key(dynago.HashKey("LockName", "report"))
+	executor.Expect("UpdateItem").ReturnError(conditionFailed)
+
+	l, err := client.TryAcquire(context.Background(), "report")
+	assert.NoError(err)
+	<-clock.waits
+
+	clock.set(start.Add(10 * time.Second))
+	clock.ticks <- start
+	assert.Equal(10*time.Second, <-clock.waits)
+	call := executor.UpdateItemCall
+	assert.Equal("SET #expires = :expires", call.UpdateExpression)
+	assert.Equal("#owner = :owner AND #token = :token", call.ConditionExpression)
+	assert.Equal(start.Add(40*time.Second).UnixMilli(), call.ExpressionAttributeValues[":expires"])
+
+	// Someone else took the lock.
+	clock.ticks <- start
+	<-l.Context().Done()
+	assert.Equal(ErrLockLost, context.Cause(l.Context()))
+	assert.Equal(ErrLockLost, l.Release())
+	assert.NoError(executor.ExpectationsMet())
+}
+
+func TestLeaseExpires(t *testing.T) {
+	assert, client, executor, clock := setUp(t)
+	executor.Expect("PutItem")
+	executor.Expect("UpdateItem").ReturnError(throttled)
+
+	l, err := client.TryAcquire(context.Background(), "report")
+	assert.NoError(err)
+	<-clock.waits
+
+	// A failed renewal leaves the lock held until the lease runs out.
+	clock.set(start.Add(25 * time.Second))
+	clock.ticks <- start
+	assert.Equal(5*time.Second, <-clock.waits)
+	assert.NoError(l.Context().Err())
+
+	clock.set(start.Add(30 * time.Second))
+	clock.ticks <- start
+	<-l.Context().Done()
+	assert.Equal(ErrLockLost, context.Cause(l.Context()))
+	assert.NoError(executor.ExpectationsMet())
+}
+
+func TestReleaseLost(t *testing.T) {
+	assert, client, executor, _ := setUp(t)
+	executor.Expect("PutItem")
+	executor.Expect("DeleteItem").ReturnError(conditionFailed)
+
+	l, err := client.TryAcquire(context.Background(), "report")
+	assert.NoError(err)
+	assert.Equal(ErrLockLost, l.Release())
+	assert.NoError(executor.ExpectationsMet())
+}
@@ -0,0 +1,414 @@
+package dynago
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+MarshalItem converts a struct, or a pointer to one, into a Document.
+
+Exported fields are stored as attributes named after the field, or after the
+name in a `dynago` struct tag. The tag can also give options after the name:
+
+	type User struct {
+		Id      int       `dynago:"UserId"`
+		Name    string    `dynago:",omitempty"` // Leave out if it's the zero value
+		Emails  []string  `dynago:",set"`       // Store as a string set instead of a list
+		Created time.Time
+		Secret  string    `dynago:"-"`          // Never stored
+		Version int       `dynago:",version"`    // Version attribute for Table
+	}
+
+Strings, numbers, bools, []byte, time.Time, Number, and the set and list
+types are stored as in a Document. Structs and maps with string keys are stored
+as maps, and slices and arrays as lists. Fields of embedded structs are stored
+as if they were fields of the outer struct. Times are converted to UTC.
+
+A Document is returned unchanged.
+*/
+func MarshalItem(item interface{}) (Document, error) {
+	switch doc := item.(type) {
+	case Document:
+		return doc, nil
+	case map[string]interface{}:
+		return Document(doc), nil
+	}
+	v := reflect.ValueOf(item)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dynago: cannot marshal %T as an item", item)
+	}
+	return marshalStruct(v)
+}
+
+/*
+UnmarshalItem copies the attributes of a Document into the struct pointed to
+by dest, using the same field names and tags as MarshalItem. Attributes with
+no matching field are ignored.
+*/
+func UnmarshalItem(doc Document, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("dynago: cannot unmarshal into non-pointer %T", dest)
+	}
+	return unmarshalValue(doc, v.Elem(), "")
+}
+
+type fieldInfo struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	set       bool
+	version   bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []fieldInfo
+
+func structFields(t reflect.Type) []fieldInfo {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]fieldInfo)
+	}
+	var fields []fieldInfo
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("dynago")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr && f.PkgPath == "" {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for _, inner := range structFields(ft) {
+					inner.index = append([]int{i}, inner.index...)
+					fields = append(fields, inner)
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		info := fieldInfo{name: f.Name, index: []int{i}}
+		parts := strings.Split(tag, ",")
+		if parts[0] != "" {
+			info.name = parts[0]
+		}
+		for _, option := range parts[1:] {
+			switch option {
+			case "omitempty":
+				info.omitEmpty = true
+			case "set":
+				info.set = true
+			case "version":
+				info.version = true
+			}
+		}
+		fields = append(fields, info)
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// The attribute of the field tagged as the version of items of type t, if any.
+func versionField(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+	for _, f := range structFields(t) {
+		if f.version {
+			return f.name
+		}
+	}
+	return ""
+}
+
+// Get a field by index, going through embedded pointers. ok is false if one is nil.
+func fieldByIndex(v reflect.Value, index []int, allocate bool) (field reflect.Value, ok bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !allocate {
+					return v, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func marshalStruct(v reflect.Value) (Document, error) {
+	doc := make(Document)
+	for _, f := range structFields(v.Type()) {
+		field, ok := fieldByIndex(v, f.index, false)
+		if !ok || (f.omitEmpty && field.IsZero()) {
+			continue
+		}
+		value, err := marshalValue(field, f.set)
+		if err != nil {
+			return nil, fmt.Errorf("%w in field %s", err, f.name)
+		}
+		if value != nil {
+			doc[f.name] = value
+		}
+	}
+	return doc, nil
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	numberType = reflect.TypeOf(Number(""))
+)
+
+// Convert a value to one which Document can encode. nil means no attribute.
+func marshalValue(v reflect.Value, set bool) (interface{}, error) {
+	switch value := v.Interface().(type) {
+	case time.Time:
+		if value.IsZero() {
+			return nil, nil
+		}
+		return value.UTC(), nil
+	case Number, Document, List, StringSet, NumberSet, BinarySet, []byte:
+		return value, nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return marshalValue(v.Elem(), set)
+	case reflect.Struct:
+		return marshalStruct(v)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if v.IsNil() {
+			return nil, nil
+		}
+		doc := make(Document, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value, err := marshalValue(iter.Value(), false)
+			if err != nil {
+				return nil, err
+			}
+			doc[iter.Key().String()] = value
+		}
+		return doc, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if set {
+			return marshalSet(v)
+		}
+		list := make(List, v.Len())
+		for i := range list {
+			value, err := marshalValue(v.Index(i), false)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("dynago: cannot marshal value of type %s", v.Type())
+}
+
+func marshalSet(v reflect.Value) (interface{}, error) {
+	if v.Len() == 0 {
+		return nil, nil // DynamoDB has no empty sets
+	}
+	elem := v.Type().Elem()
+	switch {
+	case elem.Kind() == reflect.String && elem != numberType:
+		set := make(StringSet, v.Len())
+		for i := range set {
+			set[i] = v.Index(i).String()
+		}
+		return set, nil
+	case elem.Kind() == reflect.Slice && elem.Elem().Kind() == reflect.Uint8:
+		set := make(BinarySet, v.Len())
+		for i := range set {
+			set[i] = v.Index(i).Bytes()
+		}
+		return set, nil
+	}
+	set := make(NumberSet, v.Len())
+	for i := range set {
+		value, err := marshalValue(v.Index(i), false)
+		if err != nil {
+			return nil, err
+		}
+		encoded, ok := wireEncode(value).(*wireNumber)
+		if !ok {
+			return nil, fmt.Errorf("dynago: cannot marshal %s as a set", v.Type())
+		}
+		set[i] = encoded.N
+	}
+	return set, nil
+}
+
+func unmarshalValue(value interface{}, dest reflect.Value, name string) error {
+	fail := func() error {
+		if name != "" {
+			return fmt.Errorf("dynago: cannot unmarshal %T into field %s of type %s", value, name, dest.Type())
+		}
+		return fmt.Errorf("dynago: cannot unmarshal %T into %s", value, dest.Type())
+	}
+	if value == nil {
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+	if v := reflect.ValueOf(value); v.Type().AssignableTo(dest.Type()) {
+		dest.Set(v)
+		return nil
+	}
+	if dest.Type() == timeType {
+		s, ok := value.(string)
+		if !ok {
+			return fail()
+		}
+		t, err := time.ParseInLocation(iso8601compact, s, time.UTC)
+		if err != nil {
+			return fmt.Errorf("dynago: cannot unmarshal %q as a time in field %s", s, name)
+		}
+		dest.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch dest.Kind() {
+	case reflect.Ptr:
+		if dest.IsNil() {
+			dest.Set(reflect.New(dest.Type().Elem()))
+		}
+		return unmarshalValue(value, dest.Elem(), name)
+	case reflect.String:
+		switch v := value.(type) {
+		case string:
+			dest.SetString(v)
+		case Number:
+			dest.SetString(string(v))
+		default:
+			return fail()
+		}
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fail()
+		}
+		dest.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(numberString(value), 10, 64)
+		if err != nil || dest.OverflowInt(n) {
+			return fail()
+		}
+		dest.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(numberString(value), 10, 64)
+		if err != nil || dest.OverflowUint(n) {
+			return fail()
+		}
+		dest.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(numberString(value), 64)
+		if err != nil {
+			return fail()
+		}
+		dest.SetFloat(f)
+	case reflect.Struct:
+		doc, ok := value.(Document)
+		if !ok {
+			return fail()
+		}
+		for _, f := range structFields(dest.Type()) {
+			attr, ok := doc[f.name]
+			if !ok {
+				continue
+			}
+			field, _ := fieldByIndex(dest, f.index, true)
+			if err := unmarshalValue(attr, field, f.name); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		doc, ok := value.(Document)
+		if !ok || dest.Type().Key().Kind() != reflect.String {
+			return fail()
+		}
+		m := reflect.MakeMapWithSize(dest.Type(), len(doc))
+		for key, attr := range doc {
+			elem := reflect.New(dest.Type().Elem()).Elem()
+			if err := unmarshalValue(attr, elem, name); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(dest.Type().Key()), elem)
+		}
+		dest.Set(m)
+	case reflect.Slice, reflect.Array:
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice {
+			return fail()
+		}
+		if dest.Kind() == reflect.Slice {
+			dest.Set(reflect.MakeSlice(dest.Type(), items.Len(), items.Len()))
+		} else if items.Len() > dest.Len() {
+			return fail()
+		}
+		for i := 0; i < items.Len(); i++ {
+			item := items.Index(i).Interface()
+			if _, ok := value.(NumberSet); ok {
+				item = Number(item.(string))
+			}
+			if err := unmarshalValue(item, dest.Index(i), name); err != nil {
+				return err
+			}
+		}
+	default:
+		return fail()
+	}
+	return nil
+}
+
+// The string form of a number from a Document, or "" if it's not a number.
+func numberString(value interface{}) string {
+	switch v := value.(type) {
+	case Number:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case int, int64, int32, int16, int8:
+		return strconv.FormatInt(anyInt(v), 10)
+	case uint, uint64, uint32, uint16, uint8:
+		return strconv.FormatUint(anyUint(v), 10)
+	}
+	return ""
+}
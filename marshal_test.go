@@ -0,0 +1,99 @@
+package dynago
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type marshalBase struct {
+	Created time.Time
+}
+
+type marshalAddress struct {
+	City string
+	Zip  *int `dynago:",omitempty"`
+}
+
+type marshalUser struct {
+	marshalBase
+	Id       int    `dynago:"UserId"`
+	Name     string `dynago:",omitempty"`
+	Score    float64
+	Active   bool
+	Tags     []string `dynago:",set"`
+	Lucky    []int    `dynago:",set"`
+	Aliases  []string
+	Address  marshalAddress
+	Previous *marshalAddress
+	Extra    map[string]int
+	Avatar   []byte
+	Secret   string `dynago:"-"`
+	private  string
+}
+
+func TestMarshalItem(t *testing.T) {
+	assert := assert.New(t)
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := marshalUser{
+		marshalBase: marshalBase{Created: created},
+		Id:          42,
+		Score:       1.5,
+		Tags:        []string{"a", "b"},
+		Lucky:       []int{7, 13},
+		Aliases:     []string{"bob"},
+		Address:     marshalAddress{City: "Paris"},
+		Extra:       map[string]int{"x": 1},
+		Avatar:      []byte{1, 2},
+		Secret:      "hunter2",
+		private:     "p",
+	}
+	doc, err := MarshalItem(&user)
+	assert.NoError(err)
+	assert.Equal(Document{
+		"Created": created,
+		"UserId":  int64(42),
+		"Score":   1.5,
+		"Active":  false,
+		"Tags":    StringSet{"a", "b"},
+		"Lucky":   NumberSet{"7", "13"},
+		"Aliases": List{"bob"},
+		"Address": Document{"City": "Paris"},
+		"Extra":   Document{"x": int64(1)},
+		"Avatar":  []byte{1, 2},
+	}, doc)
+
+	// Round trip through the wire format.
+	buf, err := json.Marshal(doc)
+	assert.NoError(err)
+	var decoded Document
+	assert.NoError(json.Unmarshal(buf, &decoded))
+	var output marshalUser
+	assert.NoError(UnmarshalItem(decoded, &output))
+	user.Secret, user.private = "", ""
+	assert.Equal(user, output)
+}
+
+func TestUnmarshalItem(t *testing.T) {
+	assert := assert.New(t)
+	var user marshalUser
+	err := UnmarshalItem(Document{"UserId": 5, "Previous": Document{"City": "Oslo", "Zip": Number("123")}, "Unknown": 1}, &user)
+	assert.NoError(err)
+	assert.Equal(5, user.Id)
+	if assert.NotNil(user.Previous) && assert.NotNil(user.Previous.Zip) {
+		assert.Equal(123, *user.Previous.Zip)
+	}
+
+	err = UnmarshalItem(Document{"UserId": "five"}, &user)
+	assert.EqualError(err, "dynago: cannot unmarshal string into field UserId of type int")
+	assert.Error(UnmarshalItem(Document{}, user))
+
+	var doc Document
+	assert.NoError(UnmarshalItem(Document{"Id": 1}, &doc))
+	assert.Equal(Document{"Id": 1}, doc)
+
+	_, err = MarshalItem(5)
+	assert.Error(err)
+}
@@ -0,0 +1,277 @@
+package dynago
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
+/*
+Operation describes a single call made through an Executor, for use by
+middleware.
+*/
+type Operation struct {
+	Name  string // DynamoDB operation name, e.g. "GetItem" or "CreateTable"
+	Table string // Empty for ListTables, and for batches spanning several tables
+	Index string // Set for a Query or Scan on a secondary index
+
+	// The request as passed to the Executor, e.g. *GetItem, *Query or
+	// *schema.CreateRequest. Middleware may replace it with another request
+	// of the same type.
+	Request interface{}
+
+	// The number of requests made to DynamoDB for the operation, including
+	// retries. It is set when the operation returns if the client's executor
+	// is, or wraps, an AwsExecutor whose Requester is an AttemptRequester,
+	// and is zero otherwise. Executors wrapping another should pass on the
+	// request they are given for the count to be kept.
+	Attempts int
+}
+
+/*
+Invoker performs an operation, returning the result the Executor returned,
+e.g. *GetItemResult for a GetItem.
+*/
+type Invoker func(op *Operation) (interface{}, error)
+
+/*
+Middleware wraps every operation a client makes, both on items and on schema.
+
+A middleware can inspect or change the operation before calling next, inspect
+or change the result, or return without calling next at all:
+
+	func logging(next dynago.Invoker) dynago.Invoker {
+		return func(op *dynago.Operation) (interface{}, error) {
+			start := time.Now()
+			result, err := next(op)
+			log.Printf("%s %s took %s, err=%v", op.Name, op.Table, time.Since(start), err)
+			return result, err
+		}
+	}
+
+	client := dynago.NewClient(executor, logging)
+
+A result returned instead of calling next must have the type the Executor
+would have returned, or be nil.
+*/
+type Middleware func(next Invoker) Invoker
+
+// middlewareExecutor runs every call of an Executor through a middleware chain.
+type middlewareExecutor struct {
+	invoke Invoker
+}
+
+func newMiddlewareExecutor(executor Executor, middleware []Middleware) *middlewareExecutor {
+	schemaExecutor := executor.SchemaExecutor()
+	invoke := func(op *Operation) (interface{}, error) {
+		// A copy of the request identifies this operation, even if the same
+		// request is executed several times at once.
+		request := op.Request
+		if copied, ok := copyRequest(request); ok {
+			request = copied
+			operationAttempts.Store(request, &op.Attempts)
+			defer operationAttempts.Delete(request)
+		}
+		return dispatch(executor, schemaExecutor, op.Name, request)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		invoke = middleware[i](invoke)
+	}
+	return &middlewareExecutor{invoke}
+}
+
+// operationAttempts holds the Attempts counter of each request being executed
+// through middleware, for AwsExecutor to add to.
+var operationAttempts sync.Map
+
+// Add attempts to the counter of the operation executing request, if any.
+func countAttempts(request interface{}, attempts int) {
+	if request == nil {
+		return
+	}
+	if counter, ok := operationAttempts.Load(request); ok {
+		*counter.(*int) += attempts
+	}
+}
+
+/*
+Count the attempts of copied, a changed copy of request made by an executor
+wrapping another, toward the operation executing request. The returned
+function forgets copied once it's been executed.
+*/
+func sameOperation(request, copied interface{}) func() {
+	counter, ok := operationAttempts.Load(request)
+	if !ok {
+		return func() {}
+	}
+	operationAttempts.Store(copied, counter)
+	return func() { operationAttempts.Delete(copied) }
+}
+
+// Make a shallow copy of a request, if it's a pointer to a struct.
+func copyRequest(request interface{}) (interface{}, bool) {
+	v := reflect.ValueOf(request)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, false
+	}
+	copied := reflect.New(v.Elem().Type())
+	copied.Elem().Set(v.Elem())
+	return copied.Interface(), true
+}
+
+// Call the executor method matching the type of the request.
+func dispatch(e Executor, s SchemaExecutor, name string, request interface{}) (interface{}, error) {
+	switch req := request.(type) {
+	case *BatchGet:
+		return e.BatchGetItem(req)
+	case *BatchWrite:
+		return e.BatchWriteItem(req)
+	case *DeleteItem:
+		return e.DeleteItem(req)
+	case *GetItem:
+		return e.GetItem(req)
+	case *PutItem:
+		return e.PutItem(req)
+	case *Query:
+		return e.Query(req)
+	case *Scan:
+		return e.Scan(req)
+	case *UpdateItem:
+		return e.UpdateItem(req)
+	case *schema.CreateRequest:
+		return s.CreateTable(req)
+	case *schema.DeleteRequest:
+		return s.DeleteTable(req)
+	case *schema.DescribeRequest:
+		return s.DescribeTable(req)
+	case *ListTables:
+		return s.ListTables(req)
+	case *schema.UpdateRequest:
+		return s.UpdateTable(req)
+	case *schema.UpdateTTLRequest:
+		return s.UpdateTimeToLive(req)
+	case *schema.DescribeTTLRequest:
+		return s.DescribeTimeToLive(req)
+	}
+	return nil, fmt.Errorf("dynago: unknown request type %T for operation %s", request, name)
+}
+
+func (e *middlewareExecutor) BatchGetItem(b *BatchGet) (*BatchGetResult, error) {
+	result, err := e.invoke(&Operation{Name: "BatchGetItem", Table: batchTable(b.gets), Request: b})
+	r, _ := result.(*BatchGetResult)
+	return r, err
+}
+
+func (e *middlewareExecutor) BatchWriteItem(b *BatchWrite) (*BatchWriteResult, error) {
+	table := batchTable(b.puts)
+	if b.puts == nil {
+		table = batchTable(b.deletes)
+	} else if b.deletes != nil && batchTable(b.deletes) != table {
+		table = ""
+	}
+	result, err := e.invoke(&Operation{Name: "BatchWriteItem", Table: table, Request: b})
+	r, _ := result.(*BatchWriteResult)
+	return r, err
+}
+
+func (e *middlewareExecutor) DeleteItem(d *DeleteItem) (*DeleteItemResult, error) {
+	result, err := e.invoke(&Operation{Name: "DeleteItem", Table: d.req.TableName, Request: d})
+	r, _ := result.(*DeleteItemResult)
+	return r, err
+}
+
+func (e *middlewareExecutor) GetItem(g *GetItem) (*GetItemResult, error) {
+	result, err := e.invoke(&Operation{Name: "GetItem", Table: g.req.TableName, Request: g})
+	r, _ := result.(*GetItemResult)
+	return r, err
+}
+
+func (e *middlewareExecutor) PutItem(p *PutItem) (*PutItemResult, error) {
+	result, err := e.invoke(&Operation{Name: "PutItem", Table: p.req.TableName, Request: p})
+	r, _ := result.(*PutItemResult)
+	return r, err
+}
+
+func (e *middlewareExecutor) Query(q *Query) (*QueryResult, error) {
+	result, err := e.invoke(&Operation{Name: "Query", Table: q.req.TableName, Index: q.req.IndexName, Request: q})
+	r, _ := result.(*QueryResult)
+	return r, err
+}
+
+func (e *middlewareExecutor) Scan(s *Scan) (*ScanResult, error) {
+	result, err := e.invoke(&Operation{Name: "Scan", Table: s.req.TableName, Index: s.req.IndexName, Request: s})
+	r, _ := result.(*ScanResult)
+	return r, err
+}
+
+func (e *middlewareExecutor) UpdateItem(u *UpdateItem) (*UpdateItemResult, error) {
+	result, err := e.invoke(&Operation{Name: "UpdateItem", Table: u.req.TableName, Request: u})
+	r, _ := result.(*UpdateItemResult)
+	return r, err
+}
+
+func (e *middlewareExecutor) SchemaExecutor() SchemaExecutor {
+	return middlewareSchemaExecutor{e}
+}
+
+type middlewareSchemaExecutor struct {
+	*middlewareExecutor
+}
+
+func (e middlewareSchemaExecutor) CreateTable(req *schema.CreateRequest) (*schema.CreateResult, error) {
+	result, err := e.invoke(&Operation{Name: "CreateTable", Table: req.TableName, Request: req})
+	r, _ := result.(*schema.CreateResult)
+	return r, err
+}
+
+func (e middlewareSchemaExecutor) DeleteTable(req *schema.DeleteRequest) (*schema.DeleteResult, error) {
+	result, err := e.invoke(&Operation{Name: "DeleteTable", Table: req.TableName, Request: req})
+	r, _ := result.(*schema.DeleteResult)
+	return r, err
+}
+
+func (e middlewareSchemaExecutor) DescribeTable(req *schema.DescribeRequest) (*schema.DescribeResponse, error) {
+	result, err := e.invoke(&Operation{Name: "DescribeTable", Table: req.TableName, Request: req})
+	r, _ := result.(*schema.DescribeResponse)
+	return r, err
+}
+
+func (e middlewareSchemaExecutor) ListTables(list *ListTables) (*schema.ListResponse, error) {
+	result, err := e.invoke(&Operation{Name: "ListTables", Request: list})
+	r, _ := result.(*schema.ListResponse)
+	return r, err
+}
+
+func (e middlewareSchemaExecutor) UpdateTable(req *schema.UpdateRequest) (*schema.UpdateResult, error) {
+	result, err := e.invoke(&Operation{Name: "UpdateTable", Table: req.TableName, Request: req})
+	r, _ := result.(*schema.UpdateResult)
+	return r, err
+}
+
+func (e middlewareSchemaExecutor) UpdateTimeToLive(req *schema.UpdateTTLRequest) (*schema.UpdateTTLResult, error) {
+	result, err := e.invoke(&Operation{Name: "UpdateTimeToLive", Table: req.TableName, Request: req})
+	r, _ := result.(*schema.UpdateTTLResult)
+	return r, err
+}
+
+func (e middlewareSchemaExecutor) DescribeTimeToLive(req *schema.DescribeTTLRequest) (*schema.DescribeTTLResult, error) {
+	result, err := e.invoke(&Operation{Name: "DescribeTimeToLive", Table: req.TableName, Request: req})
+	r, _ := result.(*schema.DescribeTTLResult)
+	return r, err
+}
+
+// The table all actions in a batch are for, or empty if there are several.
+func batchTable(actions *batchAction) string {
+	if actions == nil {
+		return ""
+	}
+	table := actions.table
+	for a := actions.next; a != nil; a = a.next {
+		if a.table != table {
+			return ""
+		}
+	}
+	return table
+}
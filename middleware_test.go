@@ -0,0 +1,113 @@
+package dynago_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rmfarrell/dynago"
+	"github.com/rmfarrell/dynago/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is a middleware recording the operations which pass through it.
+type recorder struct {
+	name string
+	log  *[]string
+	ops  []dynago.Operation
+}
+
+func (r *recorder) middleware(next dynago.Invoker) dynago.Invoker {
+	return func(op *dynago.Operation) (interface{}, error) {
+		r.ops = append(r.ops, *op)
+		*r.log = append(*r.log, r.name+" before")
+		result, err := next(op)
+		*r.log = append(*r.log, r.name+" after")
+		return result, err
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	assert := assert.New(t)
+	var log []string
+	outer, inner := &recorder{name: "outer", log: &log}, &recorder{name: "inner", log: &log}
+	executor := &dynago.MockExecutor{GetItemResult: &dynago.GetItemResult{Item: dynago.Document{"Id": 5}}}
+	client := dynago.NewClient(executor, outer.middleware, inner.middleware)
+
+	result, err := client.GetItem("table1", dynago.HashKey("Id", 5)).Execute()
+	assert.NoError(err)
+	assert.Equal(dynago.Document{"Id": 5}, result.Item)
+	assert.True(executor.GetItemCalled)
+	assert.Equal([]string{"outer before", "inner before", "inner after", "outer after"}, log)
+	assert.Equal("GetItem", outer.ops[0].Name)
+	assert.Equal("table1", outer.ops[0].Table)
+	assert.IsType(&dynago.GetItem{}, outer.ops[0].Request)
+}
+
+func TestMiddlewareOperations(t *testing.T) {
+	assert := assert.New(t)
+	var log []string
+	r := &recorder{log: &log}
+	client := dynago.NewClient(&dynago.MockExecutor{}, r.middleware)
+
+	client.Query("table1").IndexName("index1").Execute()
+	client.Scan("table2").Execute()
+	client.BatchGet().Get("table1", dynago.HashKey("Id", 1)).Get("table2", dynago.HashKey("Id", 2)).Execute()
+	client.BatchWrite().Put("table3", dynago.Document{"Id": 1}).Delete("table3", dynago.HashKey("Id", 2)).Execute()
+	client.UpdateTable(schema.NewUpdateRequest("table4").Throughput(5, 5))
+
+	assert.Equal(5, len(r.ops))
+	check := func(op dynago.Operation, name, table, index string) {
+		assert.Equal(name, op.Name)
+		assert.Equal(table, op.Table, name)
+		assert.Equal(index, op.Index, name)
+	}
+	check(r.ops[0], "Query", "table1", "index1")
+	check(r.ops[1], "Scan", "table2", "")
+	check(r.ops[2], "BatchGetItem", "", "")
+	check(r.ops[3], "BatchWriteItem", "table3", "")
+	check(r.ops[4], "UpdateTable", "table4", "")
+	assert.IsType(&schema.UpdateRequest{}, r.ops[4].Request)
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	assert := assert.New(t)
+	denied := errors.New("denied")
+	readOnly := func(next dynago.Invoker) dynago.Invoker {
+		return func(op *dynago.Operation) (interface{}, error) {
+			if op.Name == "PutItem" || op.Name == "DeleteTable" {
+				return nil, denied
+			}
+			return next(op)
+		}
+	}
+	executor := &dynago.MockExecutor{}
+	client := dynago.NewClient(executor, readOnly)
+
+	result, err := client.PutItem("table1", dynago.Document{"Id": 1}).Execute()
+	assert.Nil(result)
+	assert.Equal(denied, err)
+	assert.False(executor.PutItemCalled)
+
+	_, err = client.DeleteTable("table1")
+	assert.Equal(denied, err)
+
+	_, err = client.GetItem("table1", dynago.HashKey("Id", 1)).Execute()
+	assert.NoError(err)
+	assert.True(executor.GetItemCalled)
+}
+
+func TestMiddlewareReplaceRequest(t *testing.T) {
+	assert := assert.New(t)
+	consistent := func(next dynago.Invoker) dynago.Invoker {
+		return func(op *dynago.Operation) (interface{}, error) {
+			if get, ok := op.Request.(*dynago.GetItem); ok {
+				op.Request = get.ConsistentRead(true)
+			}
+			return next(op)
+		}
+	}
+	executor := &dynago.MockExecutor{}
+	client := dynago.NewClient(executor, consistent)
+	client.GetItem("table1", dynago.HashKey("Id", 1)).Execute()
+	assert.True(executor.GetItemCall.ConsistentRead)
+}
@@ -0,0 +1,94 @@
+package dynago
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
+// ErrRecreateRequired is returned when applying a plan which has changes that need the table recreated.
+var ErrRecreateRequired = errors.New("dynago: table must be recreated to apply schema changes")
+
+// MigrateOptions controls how ApplyPlan and Migrate run.
+type MigrateOptions struct {
+	// If DryRun is true, the plan is printed to Output and nothing is changed.
+	DryRun bool
+
+	// Output receives the plan and progress of each step. Defaults to
+	// os.Stdout for dry runs and is silent otherwise.
+	Output io.Writer
+
+	// Wait controls how we wait for the table to become ACTIVE after each step.
+	Wait *WaitOptions
+}
+
+/*
+PlanMigration compares a desired table definition with the table as it exists
+now, and returns the steps required to reconcile them.
+*/
+func (c *Client) PlanMigration(desired *schema.CreateRequest) (*schema.Plan, error) {
+	var current *schema.TableDescription
+	resp, err := c.DescribeTable(desired.TableName)
+	if err == nil {
+		current = &resp.Table
+	} else if !errors.Is(err, ErrorNotFound) {
+		return nil, err
+	}
+	return schema.NewPlan(desired, current)
+}
+
+/*
+ApplyPlan executes each step of the plan in order, waiting for the table and
+all its global secondary indexes to become ACTIVE after every step.
+
+If the plan requires the table to be recreated, nothing is applied and an
+error wrapping ErrRecreateRequired is returned. Dynago never deletes tables as
+part of a migration.
+*/
+func (c *Client) ApplyPlan(plan *schema.Plan, opts *MigrateOptions) error {
+	if opts == nil {
+		opts = &MigrateOptions{}
+	}
+	out := opts.Output
+	if opts.DryRun {
+		if out == nil {
+			out = os.Stdout
+		}
+		_, err := io.WriteString(out, plan.String())
+		return err
+	}
+	if plan.RequiresRecreate() {
+		return fmt.Errorf("%w: %s", ErrRecreateRequired, strings.Join(plan.Recreate, "; "))
+	}
+	for i, step := range plan.Steps {
+		if out != nil {
+			fmt.Fprintf(out, "%s: step %d/%d: %s\n", plan.TableName, i+1, len(plan.Steps), step.Description)
+		}
+		var err error
+		if step.Create != nil {
+			_, err = c.CreateTable(step.Create)
+		} else {
+			_, err = c.UpdateTable(step.Update)
+		}
+		if err == nil {
+			_, err = c.WaitForTable(plan.TableName, TableActive, opts.Wait)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: step %d (%s) failed: %w", plan.TableName, i+1, step.Description, err)
+		}
+	}
+	return nil
+}
+
+// Migrate is a shortcut to PlanMigration followed by ApplyPlan.
+func (c *Client) Migrate(desired *schema.CreateRequest, opts *MigrateOptions) (*schema.Plan, error) {
+	plan, err := c.PlanMigration(desired)
+	if err == nil {
+		err = c.ApplyPlan(plan, opts)
+	}
+	return plan, err
+}
@@ -0,0 +1,73 @@
+package dynago
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
+// migrateExecutor fakes just enough of a SchemaExecutor to apply plans.
+type migrateExecutor struct {
+	describeSequence
+	creates []*schema.CreateRequest
+	updates []*schema.UpdateRequest
+}
+
+func (m *migrateExecutor) CreateTable(req *schema.CreateRequest) (*schema.CreateResult, error) {
+	m.creates = append(m.creates, req)
+	return &schema.CreateResult{}, nil
+}
+
+func (m *migrateExecutor) UpdateTable(req *schema.UpdateRequest) (*schema.UpdateResult, error) {
+	m.updates = append(m.updates, req)
+	return &schema.UpdateResult{}, nil
+}
+
+func TestMigrateCreate(t *testing.T) {
+	assert, _, _ := setUp(t)
+	fake := &migrateExecutor{}
+	fake.tables = []*schema.TableDescription{nil, {TableStatus: schema.StatusCreating}, {TableStatus: schema.StatusActive}}
+	client := &Client{executor: &MockExecutor{}, schemaExecutor: fake}
+	desired := schema.NewCreateRequest("Foo").HashKey("Id", schema.Number)
+
+	var out bytes.Buffer
+	plan, err := client.Migrate(desired, &MigrateOptions{Output: &out, Wait: &WaitOptions{MinDelay: time.Millisecond}})
+	assert.NoError(err)
+	assert.Equal(1, len(plan.Steps))
+	assert.Equal([]*schema.CreateRequest{desired}, fake.creates)
+	assert.Equal(3, fake.calls)
+	assert.Equal("Foo: step 1/1: create table \"Foo\"\n", out.String())
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	assert, _, _ := setUp(t)
+	fake := &migrateExecutor{}
+	fake.tables = []*schema.TableDescription{{TableName: "Foo", TableStatus: schema.StatusActive}}
+	client := &Client{executor: &MockExecutor{}, schemaExecutor: fake}
+	desired := schema.NewCreateRequest("Foo")
+	desired.ProvisionedThroughput = schema.NewThroughput(3, 4)
+
+	var out bytes.Buffer
+	plan, err := client.Migrate(desired, &MigrateOptions{DryRun: true, Output: &out})
+	assert.NoError(err)
+	assert.Equal(1, len(plan.Steps))
+	assert.Equal(0, len(fake.updates))
+	assert.Equal("Plan for table \"Foo\":\n  1. change table throughput to 3 read / 4 write\n", out.String())
+
+	// Now actually apply it
+	assert.NoError(client.ApplyPlan(plan, nil))
+	assert.Equal(1, len(fake.updates))
+	assert.Equal(&schema.ProvisionedThroughput{ReadCapacityUnits: 3, WriteCapacityUnits: 4}, fake.updates[0].ProvisionedThroughput)
+}
+
+func TestApplyPlanRecreate(t *testing.T) {
+	assert, _, _ := setUp(t)
+	client := &Client{executor: &MockExecutor{}, schemaExecutor: &migrateExecutor{}}
+	plan := &schema.Plan{TableName: "Foo", Recreate: []string{"key schema changed"}}
+	err := client.ApplyPlan(plan, nil)
+	assert.True(errors.Is(err, ErrRecreateRequired))
+	assert.Equal("dynago: table must be recreated to apply schema changes: key schema changed", err.Error())
+}
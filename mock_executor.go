@@ -1,5 +1,11 @@
 package dynago
 
+import (
+	"sync"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
 /*
 MockExecutor is a fake executor to help with building tests.
 
@@ -31,6 +37,20 @@ to the application.
 		... and so on
 	}
 
+To script a sequence of calls, such as pages of a query or an error followed
+by a successful retry, use Expect instead of the result fields:
+
+	executor.Expect("GetItem").Table("mytable").ReturnError(throttled)
+	executor.Expect("GetItem").Table("mytable").Return(&dynago.GetItemResult{Item: doc})
+
+	// call into application
+
+	assert.NoError(executor.ExpectationsMet())
+
+Calls can be made from multiple goroutines, but the fields should only be read
+or changed while no calls are running. A MockExecutor must not be copied after
+first use.
+
 */
 type MockExecutor struct {
 	Calls []MockExecutorCall // All calls made through this executor
@@ -53,9 +73,11 @@ type MockExecutor struct {
 	BatchGetItemCalled bool
 	BatchGetItemCall   *MockExecutorCall
 	BatchGetItemResult *BatchGetResult
+	BatchGetItemError  error
 
 	BatchWriteItemCalled bool
 	BatchWriteItemCall   *MockExecutorCall
+	BatchWriteItemResult *BatchWriteResult // An empty result is returned if not set
 	BatchWriteItemError  error
 
 	QueryCalled bool              // True if query was called at least once
@@ -72,6 +94,44 @@ type MockExecutor struct {
 	UpdateItemCall   *MockExecutorCall
 	UpdateItemResult *UpdateItemResult
 	UpdateItemError  error
+
+	UpdateTableCalled bool
+	UpdateTableCall   *MockExecutorCall
+	UpdateTableResult *schema.UpdateResult
+	UpdateTableError  error
+
+	CreateTableCalled bool
+	CreateTableCall   *MockExecutorCall
+	CreateTableResult *schema.CreateResult
+	CreateTableError  error
+
+	DeleteTableCalled bool
+	DeleteTableCall   *MockExecutorCall
+	DeleteTableResult *schema.DeleteResult
+	DeleteTableError  error
+
+	DescribeTableCalled bool
+	DescribeTableCall   *MockExecutorCall
+	DescribeTableResult *schema.DescribeResponse
+	DescribeTableError  error
+
+	ListTablesCalled bool
+	ListTablesCall   *MockExecutorCall
+	ListTablesResult *schema.ListResponse // An empty result is returned if not set
+	ListTablesError  error
+
+	UpdateTimeToLiveCalled bool
+	UpdateTimeToLiveCall   *MockExecutorCall
+	UpdateTimeToLiveResult *schema.UpdateTTLResult
+	UpdateTimeToLiveError  error
+
+	DescribeTimeToLiveCalled bool
+	DescribeTimeToLiveCall   *MockExecutorCall
+	DescribeTimeToLiveResult *schema.DescribeTTLResult
+	DescribeTimeToLiveError  error
+
+	lock         sync.Mutex // Held during calls, so they can be made from multiple goroutines
+	expectations mockExpectations
 }
 
 /*
@@ -112,48 +172,78 @@ type MockExecutorCall struct {
 	BatchWrites BatchWriteTableMap
 	BatchGets   BatchGetTableMap
 
-	ReturnConsumedCapacity CapacityDetail
+	ReturnConsumedCapacity      CapacityDetail
+	ReturnItemCollectionMetrics ItemCollectionMetricsDetail
+
+	// Schema calls
+	CreateTable             *schema.CreateRequest
+	UpdateTable             *schema.UpdateRequest
+	UpdateTimeToLive        *schema.UpdateTTLRequest
+	ExclusiveStartTableName string
 }
 
 func (e *MockExecutor) BatchGetItem(batchGet *BatchGet) (*BatchGetResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 	e.BatchGetItemCalled = true
-	e.addCall(&e.BatchGetItemCall, MockExecutorCall{
+	call := MockExecutorCall{
 		Method:    "BatchGetItem",
 		BatchGets: batchGet.buildTableMap(),
 
 		ReturnConsumedCapacity: batchGet.capacityDetail,
-	})
-	return e.BatchGetItemResult, nil
+	}
+	e.addCall(&e.BatchGetItemCall, call)
+	result, err := e.respond(call, e.BatchGetItemResult, e.BatchGetItemError)
+	r, _ := result.(*BatchGetResult)
+	return r, err
 }
 
 func (e *MockExecutor) BatchWriteItem(batchWrite *BatchWrite) (*BatchWriteResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 	e.BatchWriteItemCalled = true
-	e.addCall(&e.BatchWriteItemCall, MockExecutorCall{
+	call := MockExecutorCall{
 		Method:      "BatchWriteItem",
 		BatchWrites: batchWrite.buildTableMap(),
 
-		ReturnConsumedCapacity: batchWrite.capacityDetail,
-	})
+		ReturnConsumedCapacity:      batchWrite.capacityDetail,
+		ReturnItemCollectionMetrics: batchWrite.collectionMetricsDetail,
+	}
+	e.addCall(&e.BatchWriteItemCall, call)
 
-	return &BatchWriteResult{}, e.BatchWriteItemError
+	defaultResult := e.BatchWriteItemResult
+	if defaultResult == nil {
+		defaultResult = &BatchWriteResult{}
+	}
+	result, err := e.respond(call, defaultResult, e.BatchWriteItemError)
+	r, _ := result.(*BatchWriteResult)
+	return r, err
 }
 
 func (e *MockExecutor) DeleteItem(deleteItem *DeleteItem) (*DeleteItemResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 	e.DeleteItemCalled = true
-	e.addCall(&e.DeleteItemCall, MockExecutorCall{
-		Method:                    "DeleteItem",
-		Table:                     deleteItem.req.TableName,
-		Key:                       deleteItem.req.Key,
-		ConditionExpression:       deleteItem.req.ConditionExpression,
-		ExpressionAttributeNames:  deleteItem.req.ExpressionAttributeNames,
-		ExpressionAttributeValues: deleteItem.req.ExpressionAttributeValues,
-		ReturnConsumedCapacity:    deleteItem.req.ReturnConsumedCapacity,
-		ReturnValues:              deleteItem.req.ReturnValues,
-	})
-	return e.DeleteItemResult, e.DeleteItemError
+	call := MockExecutorCall{
+		Method:                      "DeleteItem",
+		Table:                       deleteItem.req.TableName,
+		Key:                         deleteItem.req.Key,
+		ConditionExpression:         deleteItem.req.ConditionExpression,
+		ExpressionAttributeNames:    deleteItem.req.ExpressionAttributeNames,
+		ExpressionAttributeValues:   deleteItem.req.ExpressionAttributeValues,
+		ReturnConsumedCapacity:      deleteItem.req.ReturnConsumedCapacity,
+		ReturnItemCollectionMetrics: deleteItem.req.ReturnItemCollectionMetrics,
+		ReturnValues:                deleteItem.req.ReturnValues,
+	}
+	e.addCall(&e.DeleteItemCall, call)
+	result, err := e.respond(call, e.DeleteItemResult, e.DeleteItemError)
+	r, _ := result.(*DeleteItemResult)
+	return r, err
 }
 
 func (e *MockExecutor) GetItem(getItem *GetItem) (*GetItemResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 	e.GetItemCalled = true
 	call := MockExecutorCall{
 		Method:                    "GetItem",
@@ -166,23 +256,30 @@ func (e *MockExecutor) GetItem(getItem *GetItem) (*GetItemResult, error) {
 		ReturnConsumedCapacity:    getItem.req.ReturnConsumedCapacity,
 	}
 	e.addCall(&e.GetItemCall, call)
-	return e.GetItemResult, e.GetItemError
+	result, err := e.respond(call, e.GetItemResult, e.GetItemError)
+	r, _ := result.(*GetItemResult)
+	return r, err
 }
 
 func (e *MockExecutor) PutItem(putItem *PutItem) (*PutItemResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 	e.PutItemCalled = true
 	call := MockExecutorCall{
-		Method:                    "PutItem",
-		Table:                     putItem.req.TableName,
-		Item:                      putItem.req.Item,
-		ReturnValues:              putItem.req.ReturnValues,
-		ConditionExpression:       putItem.req.ConditionExpression,
-		ExpressionAttributeNames:  putItem.req.ExpressionAttributeNames,
-		ExpressionAttributeValues: putItem.req.ExpressionAttributeValues,
-		ReturnConsumedCapacity:    putItem.req.ReturnConsumedCapacity,
+		Method:                      "PutItem",
+		Table:                       putItem.req.TableName,
+		Item:                        putItem.req.Item,
+		ReturnValues:                putItem.req.ReturnValues,
+		ConditionExpression:         putItem.req.ConditionExpression,
+		ExpressionAttributeNames:    putItem.req.ExpressionAttributeNames,
+		ExpressionAttributeValues:   putItem.req.ExpressionAttributeValues,
+		ReturnConsumedCapacity:      putItem.req.ReturnConsumedCapacity,
+		ReturnItemCollectionMetrics: putItem.req.ReturnItemCollectionMetrics,
 	}
 	e.addCall(&e.PutItemCall, call)
-	return e.PutItemResult, e.PutItemError
+	result, err := e.respond(call, e.PutItemResult, e.PutItemError)
+	r, _ := result.(*PutItemResult)
+	return r, err
 }
 
 func callFromQueryReq(req queryRequest) MockExecutorCall {
@@ -213,49 +310,184 @@ func callFromQueryReq(req queryRequest) MockExecutorCall {
 }
 
 func (e *MockExecutor) Query(query *Query) (*QueryResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 	e.QueryCalled = true
-	e.addCall(&e.QueryCall, callFromQueryReq(query.req))
-
-	result := e.QueryResult
-	if result != nil {
-		result.query = query
-		if result.Count == 0 {
-			result.Count = len(result.Items)
+	call := callFromQueryReq(query.req)
+	e.addCall(&e.QueryCall, call)
+
+	result, err := e.respond(call, e.QueryResult, e.QueryError)
+	r, _ := result.(*QueryResult)
+	if r != nil {
+		// Copy the result, which may be returned by several calls.
+		copied := *r
+		r = &copied
+		r.query = query
+		if r.Count == 0 {
+			r.Count = len(r.Items)
 		}
-		if result.ScannedCount == 0 {
-			result.ScannedCount = result.Count
+		if r.ScannedCount == 0 {
+			r.ScannedCount = r.Count
 		}
 	}
-
-	return result, e.QueryError
+	return r, err
 }
 
 func (e *MockExecutor) Scan(scan *Scan) (*ScanResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 	e.ScanCalled = true
 	call := callFromQueryReq(scan.req.queryRequest)
 	call.Method = "Scan"
 	call.Segment = scan.req.Segment
 	call.TotalSegments = scan.req.TotalSegments
 	e.addCall(&e.ScanCall, call)
-	return e.ScanResult, e.ScanError
+
+	result, err := e.respond(call, e.ScanResult, e.ScanError)
+	r, _ := result.(*ScanResult)
+	if r != nil {
+		copied := *r
+		r = &copied
+		r.req = scan
+	}
+	return r, err
 }
 
 func (e *MockExecutor) UpdateItem(update *UpdateItem) (*UpdateItemResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
 	e.UpdateItemCalled = true
-	e.addCall(&e.UpdateItemCall, MockExecutorCall{
-		Method:                    "UpdateItem",
-		Table:                     update.req.TableName,
-		UpdateExpression:          update.req.UpdateExpression,
-		ConditionExpression:       update.req.ConditionExpression,
-		ExpressionAttributeNames:  update.req.ExpressionAttributeNames,
-		ExpressionAttributeValues: update.req.ExpressionAttributeValues,
-	})
-	return e.UpdateItemResult, e.UpdateItemError
+	call := MockExecutorCall{
+		Method:                      "UpdateItem",
+		Table:                       update.req.TableName,
+		Key:                         update.req.Key,
+		UpdateExpression:            update.req.UpdateExpression,
+		ConditionExpression:         update.req.ConditionExpression,
+		ExpressionAttributeNames:    update.req.ExpressionAttributeNames,
+		ExpressionAttributeValues:   update.req.ExpressionAttributeValues,
+		ReturnConsumedCapacity:      update.req.ReturnConsumedCapacity,
+		ReturnItemCollectionMetrics: update.req.ReturnItemCollectionMetrics,
+		ReturnValues:                update.req.ReturnValues,
+	}
+	e.addCall(&e.UpdateItemCall, call)
+	result, err := e.respond(call, e.UpdateItemResult, e.UpdateItemError)
+	r, _ := result.(*UpdateItemResult)
+	return r, err
 }
 
-// Currently we don't implement mocking for SchemaExecutor. Returns nil.
+// SchemaExecutor returns a SchemaExecutor which records calls on this MockExecutor.
 func (e *MockExecutor) SchemaExecutor() SchemaExecutor {
-	return nil
+	return mockSchemaExecutor{e}
+}
+
+type mockSchemaExecutor struct {
+	*MockExecutor
+}
+
+func (e mockSchemaExecutor) CreateTable(req *schema.CreateRequest) (*schema.CreateResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.CreateTableCalled = true
+	call := MockExecutorCall{
+		Method:      "CreateTable",
+		Table:       req.TableName,
+		CreateTable: req,
+	}
+	e.addCall(&e.CreateTableCall, call)
+	result, err := e.respond(call, e.CreateTableResult, e.CreateTableError)
+	r, _ := result.(*schema.CreateResult)
+	return r, err
+}
+
+func (e mockSchemaExecutor) DeleteTable(req *schema.DeleteRequest) (*schema.DeleteResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.DeleteTableCalled = true
+	call := MockExecutorCall{
+		Method: "DeleteTable",
+		Table:  req.TableName,
+	}
+	e.addCall(&e.DeleteTableCall, call)
+	result, err := e.respond(call, e.DeleteTableResult, e.DeleteTableError)
+	r, _ := result.(*schema.DeleteResult)
+	return r, err
+}
+
+func (e mockSchemaExecutor) DescribeTable(req *schema.DescribeRequest) (*schema.DescribeResponse, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.DescribeTableCalled = true
+	call := MockExecutorCall{
+		Method: "DescribeTable",
+		Table:  req.TableName,
+	}
+	e.addCall(&e.DescribeTableCall, call)
+	result, err := e.respond(call, e.DescribeTableResult, e.DescribeTableError)
+	r, _ := result.(*schema.DescribeResponse)
+	return r, err
+}
+
+func (e mockSchemaExecutor) ListTables(list *ListTables) (*schema.ListResponse, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.ListTablesCalled = true
+	call := MockExecutorCall{
+		Method:                  "ListTables",
+		Limit:                   list.req.Limit,
+		ExclusiveStartTableName: list.req.ExclusiveStartTableName,
+	}
+	e.addCall(&e.ListTablesCall, call)
+	defaultResult := e.ListTablesResult
+	if defaultResult == nil {
+		defaultResult = &schema.ListResponse{}
+	}
+	result, err := e.respond(call, defaultResult, e.ListTablesError)
+	r, _ := result.(*schema.ListResponse)
+	return r, err
+}
+
+func (e mockSchemaExecutor) UpdateTimeToLive(req *schema.UpdateTTLRequest) (*schema.UpdateTTLResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.UpdateTimeToLiveCalled = true
+	call := MockExecutorCall{
+		Method:           "UpdateTimeToLive",
+		Table:            req.TableName,
+		UpdateTimeToLive: req,
+	}
+	e.addCall(&e.UpdateTimeToLiveCall, call)
+	result, err := e.respond(call, e.UpdateTimeToLiveResult, e.UpdateTimeToLiveError)
+	r, _ := result.(*schema.UpdateTTLResult)
+	return r, err
+}
+
+func (e mockSchemaExecutor) DescribeTimeToLive(req *schema.DescribeTTLRequest) (*schema.DescribeTTLResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.DescribeTimeToLiveCalled = true
+	call := MockExecutorCall{
+		Method: "DescribeTimeToLive",
+		Table:  req.TableName,
+	}
+	e.addCall(&e.DescribeTimeToLiveCall, call)
+	result, err := e.respond(call, e.DescribeTimeToLiveResult, e.DescribeTimeToLiveError)
+	r, _ := result.(*schema.DescribeTTLResult)
+	return r, err
+}
+
+func (e mockSchemaExecutor) UpdateTable(req *schema.UpdateRequest) (*schema.UpdateResult, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.UpdateTableCalled = true
+	call := MockExecutorCall{
+		Method:      "UpdateTable",
+		Table:       req.TableName,
+		UpdateTable: req,
+	}
+	e.addCall(&e.UpdateTableCall, call)
+	result, err := e.respond(call, e.UpdateTableResult, e.UpdateTableError)
+	r, _ := result.(*schema.UpdateResult)
+	return r, err
 }
 
 // Reduce boilerplate on adding a call
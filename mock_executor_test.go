@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/rmfarrell/dynago"
+	"github.com/rmfarrell/dynago/schema"
 )
 
 func mockSetup(t *testing.T) (*assert.Assertions, *dynago.Client, *dynago.MockExecutor) {
@@ -181,3 +182,139 @@ func TestMockExecutorUpdateItem(t *testing.T) {
 	assert.Equal(map[string]string{"#foo": "Foo"}, executor.UpdateItemCall.ExpressionAttributeNames)
 	assert.Equal(dynago.Document{":param1": 90}, executor.UpdateItemCall.ExpressionAttributeValues)
 }
+
+func TestMockExecutorUpdateTable(t *testing.T) {
+	assert, client, executor := mockSetup(t)
+	executor.UpdateTableResult = &schema.UpdateResult{}
+	req := schema.NewUpdateRequest("table6").Throughput(5, 10).DeleteGlobalIndex("index1")
+	result, err := client.UpdateTable(req)
+	assert.NoError(err)
+	assert.Equal(executor.UpdateTableResult, result)
+	assert.Equal(true, executor.UpdateTableCalled)
+	assert.Equal("UpdateTable", executor.UpdateTableCall.Method)
+	assert.Equal("table6", executor.UpdateTableCall.Table)
+	assert.Equal(req, executor.UpdateTableCall.UpdateTable)
+	assert.Equal(executor.Calls[0], *executor.UpdateTableCall)
+}
+
+func TestMockExecutorItemCollectionMetrics(t *testing.T) {
+	assert, client, executor := mockSetup(t)
+	metrics := dynago.BatchItemCollectionMetrics{"table1": {{SizeEstimateRangeGB: []float64{0, 1}}}}
+	executor.BatchWriteItemResult = &dynago.BatchWriteResult{ItemCollectionMetrics: metrics}
+	result, err := client.BatchWrite().
+		Put("table1", dynago.Document{"Id": 1}).
+		ReturnItemCollectionMetrics(dynago.ItemCollectionMetricsSize).
+		Execute()
+	assert.NoError(err)
+	assert.Equal(metrics, result.ItemCollectionMetrics)
+	assert.Equal(dynago.ItemCollectionMetricsSize, executor.BatchWriteItemCall.ReturnItemCollectionMetrics)
+
+	client.UpdateItem("table1", dynago.HashKey("Id", 1)).ReturnItemCollectionMetrics(dynago.ItemCollectionMetricsSize).Execute()
+	assert.Equal(dynago.ItemCollectionMetricsSize, executor.UpdateItemCall.ReturnItemCollectionMetrics)
+}
+
+func TestMockExecutorBatchGetItemError(t *testing.T) {
+	assert, client, executor := mockSetup(t)
+	executor.BatchGetItemError = &dynago.Error{Type: dynago.ErrorThrottling}
+	_, err := client.BatchGet().Get("table1", dynago.HashKey("Id", 1)).Execute()
+	assert.Equal(executor.BatchGetItemError, err)
+}
+
+func TestMockExecutorSchema(t *testing.T) {
+	assert, client, executor := mockSetup(t)
+	executor.DescribeTableResult = &schema.DescribeResponse{Table: schema.TableDescription{TableName: "table1"}}
+	resp, err := client.DescribeTable("table1")
+	assert.NoError(err)
+	assert.Equal(executor.DescribeTableResult, resp)
+	assert.Equal("table1", executor.DescribeTableCall.Table)
+
+	req := schema.NewCreateRequest("table2").HashKey("Id", schema.Number)
+	client.CreateTable(req)
+	assert.Equal(true, executor.CreateTableCalled)
+	assert.Equal(req, executor.CreateTableCall.CreateTable)
+
+	executor.DeleteTableError = &dynago.Error{Type: dynago.ErrorNotFound}
+	_, err = client.DeleteTable("table3")
+	assert.Equal(executor.DeleteTableError, err)
+	assert.Equal("table3", executor.DeleteTableCall.Table)
+
+	client.UpdateTimeToLive("table4", "Expires", true)
+	assert.Equal("Expires", executor.UpdateTimeToLiveCall.UpdateTimeToLive.TimeToLiveSpecification.AttributeName)
+	client.DescribeTimeToLive("table4")
+	assert.Equal("table4", executor.DescribeTimeToLiveCall.Table)
+
+	tables, err := client.ListTables().Limit(10).Execute()
+	assert.NoError(err)
+	assert.Empty(tables.TableNames)
+	assert.Equal(uint(10), executor.ListTablesCall.Limit)
+	assert.Equal(6, len(executor.Calls))
+}
+
+func TestMockExecutorExpectPagination(t *testing.T) {
+	assert, client, executor := mockSetup(t)
+	doc1, doc2 := dynago.Document{"Id": 1}, dynago.Document{"Id": 2}
+	executor.Expect("Query").Table("table1").Return(&dynago.QueryResult{
+		Items:            []dynago.Document{doc1},
+		LastEvaluatedKey: dynago.HashKey("Id", 1),
+	})
+	executor.Expect("Query").Table("table1").Match(func(call *dynago.MockExecutorCall) bool {
+		return call.ExclusiveStartKey != nil
+	}).Return(&dynago.QueryResult{Items: []dynago.Document{doc2}})
+
+	var items []dynago.Document
+	for query := client.Query("table1"); query != nil; {
+		result, err := query.Execute()
+		if !assert.NoError(err) {
+			break
+		}
+		items = append(items, result.Items...)
+		query = result.Next()
+	}
+	assert.Equal([]dynago.Document{doc1, doc2}, items)
+	assert.Equal(dynago.HashKey("Id", 1), executor.Calls[1].ExclusiveStartKey)
+	assert.NoError(executor.ExpectationsMet())
+}
+
+func TestMockExecutorExpectRetry(t *testing.T) {
+	assert, client, executor := mockSetup(t)
+	doc := dynago.Document{"Id": 5, "Name": "five"}
+	throttled := &dynago.Error{Type: dynago.ErrorThrottling}
+	executor.Expect("GetItem").Key(dynago.HashKey("Id", 5)).ReturnError(throttled).Times(2)
+	executor.Expect("GetItem").Key(dynago.HashKey("Id", 5)).Return(&dynago.GetItemResult{Item: doc})
+
+	get := client.GetItem("table1", dynago.HashKey("Id", 5))
+	_, err := get.Execute()
+	assert.Equal(throttled, err)
+	_, err = get.Execute()
+	assert.Equal(throttled, err)
+	result, err := get.Execute()
+	assert.NoError(err)
+	assert.Equal(doc, result.Item)
+	assert.NoError(executor.ExpectationsMet())
+
+	// Once expectations are used up, further calls are unexpected.
+	_, err = get.Execute()
+	assert.Error(err)
+	assert.Error(executor.ExpectationsMet())
+}
+
+func TestMockExecutorExpectUnmet(t *testing.T) {
+	assert, client, executor := mockSetup(t)
+	executor.PutItemResult = &dynago.PutItemResult{}
+	executor.Expect("PutItem").Table("table1").Key(dynago.HashKey("Id", 1)).AnyTimes()
+	executor.Expect("DeleteItem").Table("table1")
+
+	_, err := client.PutItem("table1", dynago.Document{"Id": 1, "Name": "one"}).Execute()
+	assert.NoError(err)
+	_, err = client.PutItem("table1", dynago.Document{"Id": 2}).Execute()
+	assert.Contains(err.Error(), `unexpected PutItem call on table "table1"`)
+
+	// Methods without expectations still use the result fields.
+	_, err = client.GetItem("table1", dynago.HashKey("Id", 1)).Execute()
+	assert.NoError(err)
+
+	err = executor.ExpectationsMet()
+	assert.Contains(err.Error(), `expected DeleteItem on table "table1" to be called 1 times, was called 0 times`)
+	assert.Contains(err.Error(), `unexpected PutItem call`)
+	assert.Panics(func() { executor.Expect("DeleteItem").Return(&dynago.PutItemResult{}) })
+}
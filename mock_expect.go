@@ -0,0 +1,258 @@
+package dynago
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
+/*
+MockExpectation is a scripted response to calls into a MockExecutor, made
+with MockExecutor.Expect.
+
+Once any expectation is set for a method, every call to that method must match
+an expectation: calls are matched against the expectations for the method in
+the order they were added, using the first one which matches and isn't used
+up. Calls which match no expectation fail with an error, and are reported by
+ExpectationsMet. Methods with no expectations keep using the MockExecutor's
+result and error fields.
+
+An expectation is used up after one call unless Times or AnyTimes is used,
+so queueing expectations returns a different result on each call:
+
+	executor.Expect("Query").Table("Posts").Return(&dynago.QueryResult{
+		Items:            page1,
+		LastEvaluatedKey: dynago.HashKey("Id", 10),
+	})
+	executor.Expect("Query").Table("Posts").Return(&dynago.QueryResult{Items: page2})
+	executor.Expect("PutItem").Key(dynago.HashKey("Id", 1)).ReturnError(conflict)
+*/
+type MockExpectation struct {
+	Method string
+
+	table    string
+	key      Document
+	matchers []func(call *MockExecutorCall) bool
+	result   interface{}
+	err      error
+	times    int // 0 means any number of times
+	calls    int
+}
+
+// Table only matches calls on the given table.
+func (x *MockExpectation) Table(table string) *MockExpectation {
+	x.table = table
+	return x
+}
+
+/*
+Key only matches calls with the given key. For PutItem, the key attributes are
+taken from the item being put.
+*/
+func (x *MockExpectation) Key(key Document) *MockExpectation {
+	x.key = key
+	return x
+}
+
+/*
+Match only matches calls for which f returns true, such as to match an
+expression:
+
+	executor.Expect("UpdateItem").Match(func(call *dynago.MockExecutorCall) bool {
+		return strings.Contains(call.UpdateExpression, "Count + :n")
+	})
+
+Match can be given multiple times, in which case all must return true.
+*/
+func (x *MockExpectation) Match(f func(call *MockExecutorCall) bool) *MockExpectation {
+	x.matchers = append(x.matchers, f)
+	return x
+}
+
+/*
+Return sets the result for matched calls. It must be the result type of the
+method, e.g. *GetItemResult for GetItem, or nil.
+
+QueryResult and ScanResult can be paginated with Next as if they came from
+DynamoDB.
+*/
+func (x *MockExpectation) Return(result interface{}) *MockExpectation {
+	if want, ok := mockResultTypes[x.Method]; ok && result != nil {
+		if got := reflect.TypeOf(result); got != want {
+			panic(fmt.Sprintf("MockExecutor: %s expectation must return %s, not %s", x.Method, want, got))
+		}
+	}
+	x.result = result
+	return x
+}
+
+// ReturnError sets the error for matched calls.
+func (x *MockExpectation) ReturnError(err error) *MockExpectation {
+	x.err = err
+	return x
+}
+
+// Times sets how many calls this expectation matches before it's used up.
+func (x *MockExpectation) Times(n int) *MockExpectation {
+	x.times = n
+	return x
+}
+
+/*
+AnyTimes lets this expectation match any number of calls, including none.
+It's never used up, so expectations added after it for the same calls are
+never used.
+*/
+func (x *MockExpectation) AnyTimes() *MockExpectation {
+	return x.Times(0)
+}
+
+func (x *MockExpectation) usedUp() bool {
+	return x.times > 0 && x.calls >= x.times
+}
+
+func (x *MockExpectation) matches(call *MockExecutorCall) bool {
+	if x.table != "" && x.table != call.Table {
+		return false
+	}
+	if x.key != nil && !mockKeyMatches(x.key, call) {
+		return false
+	}
+	for _, f := range x.matchers {
+		if !f(call) {
+			return false
+		}
+	}
+	return true
+}
+
+func (x *MockExpectation) String() string {
+	s := x.Method
+	if x.table != "" {
+		s += fmt.Sprintf(" on table %q", x.table)
+	}
+	if x.key != nil {
+		s += fmt.Sprintf(" with key %v", x.key)
+	}
+	return s
+}
+
+func mockKeyMatches(key Document, call *MockExecutorCall) bool {
+	if call.Key != nil {
+		return reflect.DeepEqual(key, call.Key)
+	}
+	if call.Item == nil {
+		return false
+	}
+	for name, value := range key {
+		if !reflect.DeepEqual(value, call.Item[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// The result types of each method, to check expectations when they're set.
+var mockResultTypes = map[string]reflect.Type{
+	"BatchGetItem":       reflect.TypeOf(&BatchGetResult{}),
+	"BatchWriteItem":     reflect.TypeOf(&BatchWriteResult{}),
+	"DeleteItem":         reflect.TypeOf(&DeleteItemResult{}),
+	"GetItem":            reflect.TypeOf(&GetItemResult{}),
+	"PutItem":            reflect.TypeOf(&PutItemResult{}),
+	"Query":              reflect.TypeOf(&QueryResult{}),
+	"Scan":               reflect.TypeOf(&ScanResult{}),
+	"UpdateItem":         reflect.TypeOf(&UpdateItemResult{}),
+	"CreateTable":        reflect.TypeOf(&schema.CreateResult{}),
+	"DeleteTable":        reflect.TypeOf(&schema.DeleteResult{}),
+	"DescribeTable":      reflect.TypeOf(&schema.DescribeResponse{}),
+	"ListTables":         reflect.TypeOf(&schema.ListResponse{}),
+	"UpdateTable":        reflect.TypeOf(&schema.UpdateResult{}),
+	"UpdateTimeToLive":   reflect.TypeOf(&schema.UpdateTTLResult{}),
+	"DescribeTimeToLive": reflect.TypeOf(&schema.DescribeTTLResult{}),
+}
+
+// mockExpectations holds the expectations of a MockExecutor.
+type mockExpectations struct {
+	lock       sync.Mutex
+	expected   []*MockExpectation
+	unexpected []MockExecutorCall
+}
+
+/*
+Expect adds an expectation for calls to method, which is the name of a
+DynamoDB operation such as "GetItem" or "CreateTable".
+See MockExpectation for how calls are matched.
+*/
+func (e *MockExecutor) Expect(method string) *MockExpectation {
+	if _, ok := mockResultTypes[method]; !ok {
+		panic(fmt.Sprintf("MockExecutor: cannot expect unknown method %q", method))
+	}
+	x := &MockExpectation{Method: method, times: 1}
+	e.expectations.lock.Lock()
+	defer e.expectations.lock.Unlock()
+	e.expectations.expected = append(e.expectations.expected, x)
+	return x
+}
+
+/*
+ExpectationsMet returns an error describing any expectations which were not
+used up and any calls which matched no expectation, or nil if there are none.
+
+	assert.NoError(t, executor.ExpectationsMet())
+*/
+func (e *MockExecutor) ExpectationsMet() error {
+	e.expectations.lock.Lock()
+	defer e.expectations.lock.Unlock()
+	var problems []string
+	for _, x := range e.expectations.expected {
+		if x.times > 0 && x.calls < x.times {
+			problems = append(problems, fmt.Sprintf("expected %s to be called %d times, was called %d times", x, x.times, x.calls))
+		}
+	}
+	for _, call := range e.expectations.unexpected {
+		problems = append(problems, mockUnexpectedCall(&call))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("MockExecutor: %s", strings.Join(problems, "; "))
+}
+
+/*
+Find the response to a call from the expectations for its method. If there are
+none, result and err are given back unchanged.
+*/
+func (e *MockExecutor) respond(call MockExecutorCall, result interface{}, err error) (interface{}, error) {
+	e.expectations.lock.Lock()
+	defer e.expectations.lock.Unlock()
+	expected := false
+	for _, x := range e.expectations.expected {
+		if x.Method != call.Method {
+			continue
+		}
+		expected = true
+		if !x.usedUp() && x.matches(&call) {
+			x.calls++
+			return x.result, x.err
+		}
+	}
+	if !expected {
+		return result, err
+	}
+	e.expectations.unexpected = append(e.expectations.unexpected, call)
+	return nil, fmt.Errorf("MockExecutor: %s", mockUnexpectedCall(&call))
+}
+
+func mockUnexpectedCall(call *MockExecutorCall) string {
+	s := "unexpected " + call.Method + " call"
+	if call.Table != "" {
+		s += fmt.Sprintf(" on table %q", call.Table)
+	}
+	if call.Key != nil {
+		s += fmt.Sprintf(" with key %v", call.Key)
+	}
+	return s
+}
@@ -0,0 +1,262 @@
+package dynago
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
+// ErrRateLimited is returned by a non-blocking RateLimiter when a table has no capacity left.
+var ErrRateLimited = errors.New("dynago: client-side rate limit exceeded")
+
+/*
+RateLimit is the capacity a client may use on a table, in capacity units per
+second. A zero rate means that kind of operation is not limited.
+*/
+type RateLimit struct {
+	ReadUnits  float64
+	WriteUnits float64
+
+	// Burst is how many units may be used at once after a quiet period.
+	// Defaults to one second's worth of units.
+	Burst float64
+}
+
+/*
+ThroughputLimit makes a RateLimit using a fraction of a table's provisioned
+throughput, such as 0.5 to leave half the capacity for other traffic:
+
+	resp, err := client.DescribeTable("Jobs")
+	limiter.SetLimit("Jobs", dynago.ThroughputLimit(resp.Table.ProvisionedThroughput, 0.5))
+*/
+func ThroughputLimit(throughput schema.ProvisionedThroughputDescription, fraction float64) RateLimit {
+	return RateLimit{
+		ReadUnits:  float64(throughput.ReadCapacityUnits) * fraction,
+		WriteUnits: float64(throughput.WriteCapacityUnits) * fraction,
+	}
+}
+
+/*
+RateLimiter limits the read and write capacity a client uses on each table,
+using a token bucket per table.
+
+Because the capacity an operation uses is only known afterwards, operations
+go ahead while a table's bucket has tokens left, and then the capacity from
+ConsumedCapacity is taken from the bucket, possibly leaving it in debt.
+ReturnConsumedCapacity is set on all limited operations which don't
+request it already. Operations which don't report consumed capacity are
+counted as one unit. This includes operations which DynamoDB rejects, such as
+writes failing their condition, since they still use capacity.
+
+The zero value limits nothing until limits are set:
+
+	limiter := &dynago.RateLimiter{}
+	limiter.SetLimit("Jobs", dynago.RateLimit{ReadUnits: 50, WriteUnits: 20})
+	client := dynago.NewClient(executor, limiter.Middleware())
+
+A RateLimiter is safe to share between goroutines and clients.
+*/
+type RateLimiter struct {
+	// If NoWait is set, operations fail with ErrRateLimited instead of
+	// waiting for capacity.
+	NoWait bool
+
+	lock   sync.Mutex
+	tables map[string]*tableBuckets
+
+	// Overridden in tests
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+type tableBuckets struct {
+	read, write *tokenBucket // nil if not limited
+}
+
+// SetLimit sets or replaces the limit for a table.
+func (l *RateLimiter) SetLimit(table string, limit RateLimit) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.tables == nil {
+		l.tables = make(map[string]*tableBuckets)
+	}
+	l.tables[table] = &tableBuckets{
+		read:  newTokenBucket(limit.ReadUnits, limit.Burst),
+		write: newTokenBucket(limit.WriteUnits, limit.Burst),
+	}
+}
+
+// RemoveLimit stops limiting a table.
+func (l *RateLimiter) RemoveLimit(table string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.tables, table)
+}
+
+// Middleware makes a middleware which applies this limiter to a client.
+func (l *RateLimiter) Middleware() Middleware {
+	return func(next Invoker) Invoker {
+		return func(op *Operation) (interface{}, error) {
+			write, limited := capacityKinds[op.Name]
+			if !limited {
+				return next(op)
+			}
+			tables := operationTables(op)
+			if err := l.acquire(tables, write); err != nil {
+				return nil, err
+			}
+			op.Request = withConsumedCapacity(op.Request, CapacityTotal)
+			result, err := next(op)
+			var dynamoErr *Error
+			if err == nil || errors.As(err, &dynamoErr) {
+				if capacity := resultCapacity(result); len(capacity) > 0 {
+					for _, c := range capacity {
+						table := c.TableName
+						if table == "" {
+							table = op.Table
+						}
+						l.charge(table, write, c.CapacityUnits)
+					}
+				} else {
+					for _, table := range tables {
+						l.charge(table, write, 1)
+					}
+				}
+			}
+			return result, err
+		}
+	}
+}
+
+// Wait until all the tables have tokens left, or fail if NoWait is set.
+func (l *RateLimiter) acquire(tables []string, write bool) error {
+	for {
+		var delay time.Duration
+		var waitTable string
+		l.lock.Lock()
+		now := l.currentTime()
+		for _, table := range tables {
+			if bucket := l.bucket(table, write); bucket != nil {
+				if d := bucket.delay(now); d > delay {
+					delay, waitTable = d, table
+				}
+			}
+		}
+		l.lock.Unlock()
+		if delay == 0 {
+			return nil
+		} else if l.NoWait {
+			return fmt.Errorf("%w on table %q (retry in %s)", ErrRateLimited, waitTable, delay)
+		}
+		if l.sleep != nil {
+			l.sleep(delay)
+		} else {
+			time.Sleep(delay)
+		}
+	}
+}
+
+func (l *RateLimiter) charge(table string, write bool, units float64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if bucket := l.bucket(table, write); bucket != nil {
+		bucket.take(l.currentTime(), units)
+	}
+}
+
+func (l *RateLimiter) bucket(table string, write bool) *tokenBucket {
+	buckets := l.tables[table]
+	if buckets == nil {
+		return nil
+	} else if write {
+		return buckets.write
+	}
+	return buckets.read
+}
+
+func (l *RateLimiter) currentTime() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+// tokenBucket holds capacity units, refilling at rate per second up to burst.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = math.Max(rate, 1)
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+b.rate*now.Sub(b.last).Seconds())
+	}
+	b.last = now
+}
+
+// How long until the bucket has tokens again.
+func (b *tokenBucket) delay(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens > 0 {
+		return 0
+	}
+	return time.Duration((-b.tokens/b.rate)*float64(time.Second)) + time.Millisecond
+}
+
+func (b *tokenBucket) take(now time.Time, units float64) {
+	b.refill(now)
+	b.tokens -= units
+}
+
+// Operations which use capacity, and whether they use write capacity.
+var capacityKinds = map[string]bool{
+	"GetItem":        false,
+	"Query":          false,
+	"Scan":           false,
+	"BatchGetItem":   false,
+	"PutItem":        true,
+	"UpdateItem":     true,
+	"DeleteItem":     true,
+	"BatchWriteItem": true,
+}
+
+// All the tables an operation acts on.
+func operationTables(op *Operation) []string {
+	if op.Table != "" {
+		return []string{op.Table}
+	}
+	seen := map[string]bool{}
+	var tables []string
+	add := func(actions *batchAction) {
+		for a := actions; a != nil; a = a.next {
+			if !seen[a.table] {
+				seen[a.table] = true
+				tables = append(tables, a.table)
+			}
+		}
+	}
+	switch req := op.Request.(type) {
+	case *BatchGet:
+		add(req.gets)
+	case *BatchWrite:
+		add(req.puts)
+		add(req.deletes)
+	}
+	return tables
+}
@@ -0,0 +1,138 @@
+package dynago
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rmfarrell/dynago/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+func rateLimitSetUp(t *testing.T) (*assert.Assertions, *RateLimiter, *fakeClock, *MockExecutor, *Client) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := &RateLimiter{now: clock.Now, sleep: clock.Sleep}
+	executor := &MockExecutor{}
+	return assert.New(t), limiter, clock, executor, NewClient(executor, limiter.Middleware())
+}
+
+func TestRateLimiterBlocks(t *testing.T) {
+	assert, limiter, clock, executor, client := rateLimitSetUp(t)
+	limiter.SetLimit("Jobs", RateLimit{ReadUnits: 10, WriteUnits: 5})
+	executor.QueryResult = &QueryResult{ConsumedCapacity: &ConsumedCapacity{TableName: "Jobs", CapacityUnits: 25}}
+
+	// The first query uses the initial burst and leaves the table 15 units in debt
+	_, err := client.Query("Jobs").Execute()
+	assert.NoError(err)
+	assert.Equal(CapacityTotal, executor.QueryCall.ReturnConsumedCapacity)
+	assert.Equal(0, len(clock.sleeps))
+
+	// The second has to wait for the debt to be paid off
+	_, err = client.Query("Jobs").Execute()
+	assert.NoError(err)
+	assert.Equal([]time.Duration{1501 * time.Millisecond}, clock.sleeps)
+
+	// Writes have their own bucket
+	_, err = client.PutItem("Jobs", Document{"Id": 1}).Execute()
+	assert.NoError(err)
+	assert.Equal(1, len(clock.sleeps))
+
+	// Other tables are not limited
+	executor.QueryResult = &QueryResult{ConsumedCapacity: &ConsumedCapacity{TableName: "Other", CapacityUnits: 1000}}
+	for i := 0; i < 3; i++ {
+		client.Query("Other").Execute()
+	}
+	assert.Equal(1, len(clock.sleeps))
+}
+
+func TestRateLimiterNoWait(t *testing.T) {
+	assert, limiter, clock, executor, client := rateLimitSetUp(t)
+	limiter.NoWait = true
+	limiter.SetLimit("Jobs", RateLimit{WriteUnits: 2})
+
+	// Without consumed capacity in the result, each write counts as one unit
+	for i := 0; i < 2; i++ {
+		_, err := client.DeleteItem("Jobs", HashKey("Id", i)).Execute()
+		assert.NoError(err)
+	}
+	assert.Equal(CapacityTotal, executor.DeleteItemCall.ReturnConsumedCapacity)
+	_, err := client.DeleteItem("Jobs", HashKey("Id", 3)).Execute()
+	assert.True(errors.Is(err, ErrRateLimited))
+	assert.Equal(`dynago: client-side rate limit exceeded on table "Jobs" (retry in 1ms)`, err.Error())
+	assert.Equal(2, len(executor.Calls))
+
+	clock.now = clock.now.Add(time.Second)
+	_, err = client.DeleteItem("Jobs", HashKey("Id", 3)).Execute()
+	assert.NoError(err)
+
+	limiter.RemoveLimit("Jobs")
+	for i := 0; i < 5; i++ {
+		_, err = client.DeleteItem("Jobs", HashKey("Id", i)).Execute()
+		assert.NoError(err)
+	}
+}
+
+func TestRateLimiterChargesFailures(t *testing.T) {
+	assert, limiter, _, executor, client := rateLimitSetUp(t)
+	limiter.NoWait = true
+	limiter.SetLimit("Jobs", RateLimit{WriteUnits: 2})
+	executor.PutItemError = &Error{Type: ErrorConditionFailed}
+	for i := 0; i < 2; i++ {
+		_, err := client.PutItem("Jobs", Document{"Id": i}).Execute()
+		assert.True(errors.Is(err, ErrorConditionFailed))
+	}
+	executor.UpdateItemError = &Error{Type: ErrorThroughputExceeded}
+	_, err := client.UpdateItem("Jobs", HashKey("Id", 1)).Execute()
+	assert.True(errors.Is(err, ErrRateLimited))
+
+	// Errors not from DynamoDB aren't charged
+	executor.UpdateItemError = errors.New("connection refused")
+	limiter.SetLimit("Jobs", RateLimit{WriteUnits: 1})
+	for i := 0; i < 2; i++ {
+		_, err = client.UpdateItem("Jobs", HashKey("Id", 1)).Execute()
+		assert.EqualError(err, "connection refused")
+	}
+}
+
+func TestRateLimiterBatch(t *testing.T) {
+	assert, limiter, clock, executor, client := rateLimitSetUp(t)
+	limiter.SetLimit("A", RateLimit{ReadUnits: 1})
+	limiter.SetLimit("B", RateLimit{ReadUnits: 4})
+	executor.BatchGetItemResult = &BatchGetResult{ConsumedCapacity: BatchConsumedCapacity{
+		{TableName: "A", CapacityUnits: 1},
+		{TableName: "B", CapacityUnits: 8},
+	}}
+	batch := client.BatchGet().Get("A", HashKey("Id", 1)).Get("B", HashKey("Id", 2))
+	batch.Execute()
+	assert.Equal(CapacityTotal, executor.BatchGetItemCall.ReturnConsumedCapacity)
+	batch.Execute()
+	assert.Equal([]time.Duration{1001 * time.Millisecond}, clock.sleeps)
+}
+
+func TestRateLimiterSkipsUnlimited(t *testing.T) {
+	assert, limiter, _, executor, client := rateLimitSetUp(t)
+	limiter.SetLimit("Jobs", RateLimit{ReadUnits: 1})
+	client.UpdateTable(schema.NewUpdateRequest("Jobs").Throughput(5, 5))
+	client.GetItem("Jobs", HashKey("Id", 1)).ReturnConsumedCapacity(CapacityIndexes).Execute()
+	assert.True(executor.UpdateTableCalled)
+	assert.Equal(CapacityIndexes, executor.GetItemCall.ReturnConsumedCapacity)
+}
+
+func TestThroughputLimit(t *testing.T) {
+	assert := assert.New(t)
+	desc := schema.ProvisionedThroughputDescription{}
+	desc.ReadCapacityUnits, desc.WriteCapacityUnits = 100, 40
+	assert.Equal(RateLimit{ReadUnits: 50, WriteUnits: 20}, ThroughputLimit(desc, 0.5))
+}
@@ -0,0 +1,293 @@
+package dynago
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrNotRecorded is returned by a Replayer for requests which aren't in its cassette.
+var ErrNotRecorded = errors.New("dynago: no recorded response for request")
+
+/*
+Cassette is a recording of requests to DynamoDB and their responses, which
+can be saved to a file with a Recorder and replayed with a Replayer.
+
+Request bodies are stored with object keys sorted, so that recordings don't
+depend on the order fields were marshaled in. Credentials and signatures are
+never recorded, but item data is.
+*/
+type Cassette struct {
+	Interactions []Interaction
+}
+
+// Interaction is a single recorded request and its response or error.
+type Interaction struct {
+	Target   string
+	Request  json.RawMessage
+	Response json.RawMessage `json:",omitempty"`
+	Error    *RecordedError  `json:",omitempty"`
+}
+
+/*
+RecordedError is a recorded error. An *Error is replayed with the fields which
+came from DynamoDB; other errors are replayed with just their message.
+*/
+type RecordedError struct {
+	Message             string
+	AmazonRawType       string               `json:",omitempty"`
+	StatusCode          int                  `json:",omitempty"`
+	RequestID           string               `json:",omitempty"`
+	CancellationReasons []CancellationReason `json:",omitempty"`
+	Item                Document             `json:",omitempty"`
+
+	Transport bool `json:",omitempty"` // Set if the error was a *TransportError
+}
+
+// LoadCassette reads a cassette saved with Save.
+func LoadCassette(path string) (*Cassette, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cassette{}
+	if err = json.Unmarshal(buf, c); err != nil {
+		return nil, fmt.Errorf("dynago: reading cassette %s: %w", path, err)
+	}
+	// Undo the indentation from Save.
+	for i := range c.Interactions {
+		interaction := &c.Interactions[i]
+		interaction.Request = canonicalJSON(interaction.Request)
+		if interaction.Response != nil {
+			interaction.Response = canonicalJSON(interaction.Response)
+		}
+	}
+	return c, nil
+}
+
+// Save writes the cassette to a file as indented JSON.
+func (c *Cassette) Save(path string) error {
+	buf, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+/*
+Recorder is an AwsRequester which records all requests made through another
+requester, normally the one an AwsExecutor was created with:
+
+	executor := dynago.NewAwsExecutor(endpoint, region, accessKey, secretKey)
+	recorder := &dynago.Recorder{Requester: executor.Requester, Path: "testdata/users.json"}
+	executor.Requester = recorder
+	client := dynago.NewClient(executor)
+
+A Recorder is safe to use from multiple goroutines.
+*/
+type Recorder struct {
+	Requester AwsRequester // Makes the real requests
+
+	// If Path is set, the cassette is saved to it after every request.
+	// Otherwise, save the recording with Cassette().Save when done.
+	Path string
+
+	lock     sync.Mutex
+	cassette Cassette
+}
+
+// MakeRequest makes a request with the underlying requester and records it.
+func (r *Recorder) MakeRequest(target string, body []byte) ([]byte, error) {
+	response, _, err := r.MakeRequestAttempts(target, body)
+	return response, err
+}
+
+/*
+MakeRequestAttempts is like MakeRequest, but also gives the number of attempts
+the underlying requester made, or 1 if it isn't an AttemptRequester.
+*/
+func (r *Recorder) MakeRequestAttempts(target string, body []byte) ([]byte, int, error) {
+	var response []byte
+	var err error
+	attempts := 1
+	if requester, ok := r.Requester.(AttemptRequester); ok {
+		response, attempts, err = requester.MakeRequestAttempts(target, body)
+	} else {
+		response, err = r.Requester.MakeRequest(target, body)
+	}
+	interaction := Interaction{Target: target, Request: canonicalJSON(body)}
+	if err != nil {
+		interaction.Error = recordError(err)
+	} else {
+		interaction.Response = canonicalJSON(response)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if r.Path != "" {
+		if saveErr := r.cassette.Save(r.Path); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+	return response, attempts, err
+}
+
+// Cassette gives a copy of everything recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return &Cassette{append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+func recordError(err error) *RecordedError {
+	var e *Error
+	if errors.As(err, &e) {
+		return &RecordedError{
+			AmazonRawType:       e.AmazonRawType,
+			Message:             e.Message,
+			StatusCode:          e.StatusCode,
+			RequestID:           e.RequestID,
+			CancellationReasons: e.CancellationReasons,
+			Item:                e.Item,
+		}
+	}
+	var transport *TransportError
+	return &RecordedError{Message: err.Error(), Transport: errors.As(err, &transport)}
+}
+
+func (r *RecordedError) err() error {
+	if r.AmazonRawType != "" {
+		e := &Error{StatusCode: r.StatusCode, RequestID: r.RequestID}
+		e.parse(&inputError{
+			AmazonRawType:       r.AmazonRawType,
+			Message:             r.Message,
+			CancellationReasons: r.CancellationReasons,
+			Item:                r.Item,
+		})
+		return e
+	} else if r.Transport {
+		return &TransportError{Err: errors.New(r.Message)}
+	}
+	return errors.New(r.Message)
+}
+
+/*
+RequestMatcher decides whether a recorded request body matches the body of a
+request being replayed, for the same target. Both bodies have their object
+keys sorted.
+*/
+type RequestMatcher func(target string, recorded, actual []byte) bool
+
+// MatchBody matches requests with identical bodies. It is the default RequestMatcher.
+func MatchBody(target string, recorded, actual []byte) bool {
+	return bytes.Equal(recorded, actual)
+}
+
+/*
+MatchBodyIgnoring matches requests with identical bodies apart from the given
+top-level fields, such as fields which change between runs:
+
+	replayer.Match = dynago.MatchBodyIgnoring("ExclusiveStartKey", "ClientRequestToken")
+*/
+func MatchBodyIgnoring(fields ...string) RequestMatcher {
+	strip := func(body []byte) []byte {
+		var decoded map[string]json.RawMessage
+		if err := json.Unmarshal(body, &decoded); err != nil {
+			return body
+		}
+		for _, field := range fields {
+			delete(decoded, field)
+		}
+		buf, _ := json.Marshal(decoded)
+		return buf
+	}
+	return func(target string, recorded, actual []byte) bool {
+		return bytes.Equal(strip(recorded), strip(actual))
+	}
+}
+
+/*
+Replayer is an AwsRequester which serves responses from a cassette without
+using the network:
+
+	cassette, err := dynago.LoadCassette("testdata/users.json")
+	executor := &dynago.AwsExecutor{Requester: &dynago.Replayer{Cassette: cassette}}
+	client := dynago.NewClient(executor)
+
+Each request is answered by the first interaction in the cassette with the same
+target and a matching body which hasn't been replayed yet, so repeated requests
+get their recorded responses in order. Requests which match nothing fail with
+ErrNotRecorded.
+
+A Replayer is safe to use from multiple goroutines.
+*/
+type Replayer struct {
+	Cassette *Cassette
+	Match    RequestMatcher // Defaults to MatchBody
+
+	lock   sync.Mutex
+	played map[int]bool
+}
+
+// MakeRequestAttempts is like MakeRequest, and gives 1 attempt, as retries aren't recorded.
+func (r *Replayer) MakeRequestAttempts(target string, body []byte) ([]byte, int, error) {
+	response, err := r.MakeRequest(target, body)
+	return response, 1, err
+}
+
+// MakeRequest gives the recorded response to a request.
+func (r *Replayer) MakeRequest(target string, body []byte) ([]byte, error) {
+	match := r.Match
+	if match == nil {
+		match = MatchBody
+	}
+	actual := canonicalJSON(body)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.played == nil {
+		r.played = make(map[int]bool)
+	}
+	for i, interaction := range r.Cassette.Interactions {
+		if r.played[i] || interaction.Target != target || !match(target, canonicalJSON(interaction.Request), actual) {
+			continue
+		}
+		r.played[i] = true
+		if interaction.Error != nil {
+			return nil, interaction.Error.err()
+		}
+		return interaction.Response, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, target, actual)
+}
+
+// Remaining gives the interactions which haven't been replayed.
+func (r *Replayer) Remaining() []Interaction {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var remaining []Interaction
+	for i, interaction := range r.Cassette.Interactions {
+		if !r.played[i] {
+			remaining = append(remaining, interaction)
+		}
+	}
+	return remaining
+}
+
+// Re-encode a JSON body with object keys sorted and numbers kept as written.
+func canonicalJSON(body []byte) json.RawMessage {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		// Not JSON; keep it as a string so the cassette stays valid.
+		buf, _ := json.Marshal(string(body))
+		return buf
+	}
+	buf, _ := json.Marshal(decoded)
+	return buf
+}
@@ -0,0 +1,87 @@
+package dynago
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	requester, _ := awsSetUp(t)
+	recorder := &Recorder{Requester: requester, Path: path}
+	client := NewClient(&AwsExecutor{recorder})
+
+	requester.returnBody = []byte(`{"Item": {"Id": {"N": "5"}, "Name": {"S": "five"}}}`)
+	result, err := client.GetItem("Users", HashKey("Id", 5)).Execute()
+	assert.NoError(err)
+	assert.Equal(Document{"Id": Number("5"), "Name": "five"}, result.Item)
+
+	requester.returnBody = nil
+	requester.returnError = buildError(nil, nil, nil, []byte(`{"__type": "com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException", "message": "The conditional request failed"}`))
+	_, err = client.PutItem("Users", Document{"Id": 5}).ConditionExpression("attribute_not_exists(Id)").Execute()
+	assert.True(errors.Is(err, ErrorConditionFailed))
+
+	requester.returnError = &TransportError{Err: errors.New("connection reset")}
+	_, err = client.GetItem("Users", HashKey("Id", 6)).Execute()
+	assert.Error(err)
+	assert.Equal(3, len(recorder.Cassette().Interactions))
+
+	cassette, err := LoadCassette(path)
+	assert.NoError(err)
+	assert.Equal(recorder.Cassette(), cassette)
+	assert.Equal("GetItem", cassette.Interactions[0].Target)
+	assert.Equal(`{"Key":{"Id":{"N":"5"}},"TableName":"Users"}`, string(cassette.Interactions[0].Request))
+
+	replayer := &Replayer{Cassette: cassette}
+	client = NewClient(&AwsExecutor{replayer})
+	_, err = client.PutItem("Users", Document{"Id": 5}).ConditionExpression("attribute_not_exists(Id)").Execute()
+	var e *Error
+	if assert.True(errors.As(err, &e)) {
+		assert.Equal(ErrorConditionFailed, e.Type)
+		assert.Equal("The conditional request failed", e.Message)
+	}
+	result, err = client.GetItem("Users", HashKey("Id", 5)).Execute()
+	assert.NoError(err)
+	assert.Equal("five", result.Item["Name"])
+	_, err = client.GetItem("Users", HashKey("Id", 6)).Execute()
+	var transport *TransportError
+	assert.True(errors.As(err, &transport))
+	assert.Empty(replayer.Remaining())
+
+	// Each interaction is only replayed once.
+	_, err = client.GetItem("Users", HashKey("Id", 5)).Execute()
+	assert.True(errors.Is(err, ErrNotRecorded))
+}
+
+func TestReplayMatchers(t *testing.T) {
+	assert := assert.New(t)
+	cassette := &Cassette{Interactions: []Interaction{
+		{Target: "Scan", Request: []byte(`{"TableName":"Users"}`), Response: []byte(`{"Items":[{"Id":{"N":"1"}}],"LastEvaluatedKey":{"Id":{"N":"1"}}}`)},
+		{Target: "Scan", Request: []byte(`{"ExclusiveStartKey":{"Id":{"N":"99"}},"TableName":"Users"}`), Response: []byte(`{"Items":[{"Id":{"N":"2"}}]}`)},
+	}}
+	replayer := &Replayer{Cassette: cassette}
+	client := NewClient(&AwsExecutor{replayer})
+
+	result, err := client.Scan("Users").Execute()
+	assert.NoError(err)
+	_, err = result.Next().Execute()
+	assert.True(errors.Is(err, ErrNotRecorded))
+
+	replayer = &Replayer{Cassette: cassette, Match: MatchBodyIgnoring("ExclusiveStartKey")}
+	client = NewClient(&AwsExecutor{replayer})
+	result, err = client.Scan("Users").Execute()
+	assert.NoError(err)
+	result, err = result.Next().Execute()
+	assert.NoError(err)
+	assert.Equal([]Document{{"Id": Number("2")}}, result.Items)
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(`{"A":{"N":"1.50"},"B":[2,1]}`, string(canonicalJSON([]byte(`{"B": [2, 1], "A": {"N": "1.50"}}`))))
+	assert.Equal(`"not json"`, string(canonicalJSON([]byte(`not json`))))
+}
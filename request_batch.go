@@ -3,7 +3,8 @@ package dynago
 type batchWriteItemRequest struct {
 	RequestItems BatchWriteTableMap
 
-	ReturnConsumedCapacity CapacityDetail `json:",omitempty"`
+	ReturnConsumedCapacity      CapacityDetail              `json:",omitempty"`
+	ReturnItemCollectionMetrics ItemCollectionMetricsDetail `json:",omitempty"`
 }
 
 // BatchWriteTableMap describes writes where the key is the table and the value is the unprocessed items.
@@ -51,7 +52,8 @@ type BatchWrite struct {
 	puts    *batchAction
 	deletes *batchAction
 
-	capacityDetail CapacityDetail
+	capacityDetail          CapacityDetail
+	collectionMetricsDetail ItemCollectionMetricsDetail
 }
 
 /*
@@ -76,9 +78,31 @@ func (b BatchWrite) ReturnConsumedCapacity(consumedCapacity CapacityDetail) *Bat
 	return &b
 }
 
-// Execute the writes in this batch.
+// ReturnItemCollectionMetrics enables item collection metrics on this BatchWrite.
+func (b BatchWrite) ReturnItemCollectionMetrics(detail ItemCollectionMetricsDetail) *BatchWrite {
+	b.collectionMetricsDetail = detail
+	return &b
+}
+
+/*
+Execute the writes in this batch.
+
+Puts to tables with a version attribute are made first, one at a time, as
+described in SetVersionAttribute. If one of them fails, its error is returned
+and the rest of the batch isn't written.
+*/
 func (b *BatchWrite) Execute() (*BatchWriteResult, error) {
-	return b.client.executor.BatchWriteItem(b)
+	batch, result, err := b.putVersioned()
+	if err != nil {
+		return result, err
+	} else if result == nil {
+		return b.client.executor.BatchWriteItem(b)
+	} else if batch.puts == nil && batch.deletes == nil {
+		return result, nil
+	}
+	batchResult, err := b.client.executor.BatchWriteItem(batch)
+	result.add(batchResult)
+	return result, err
 }
 
 // Build the table map that is represented by this BatchWrite
@@ -103,18 +127,20 @@ func (b *BatchWrite) buildTableMap() (m BatchWriteTableMap) {
 // BatchWriteItem executes multiple puts/deletes in a single roundtrip.
 func (e *AwsExecutor) BatchWriteItem(b *BatchWrite) (result *BatchWriteResult, err error) {
 	req := batchWriteItemRequest{
-		RequestItems:           b.buildTableMap(),
-		ReturnConsumedCapacity: b.capacityDetail,
+		RequestItems:                b.buildTableMap(),
+		ReturnConsumedCapacity:      b.capacityDetail,
+		ReturnItemCollectionMetrics: b.collectionMetricsDetail,
 	}
 
-	err = e.MakeRequestUnmarshal("BatchWriteItem", req, &result)
+	err = e.sendUnmarshal(b, "BatchWriteItem", req, &result)
 	return
 }
 
 // BatchWriteResult explains what happened in a batch write.
 type BatchWriteResult struct {
-	UnprocessedItems BatchWriteTableMap
-	ConsumedCapacity BatchConsumedCapacity
+	UnprocessedItems      BatchWriteTableMap
+	ConsumedCapacity      BatchConsumedCapacity
+	ItemCollectionMetrics BatchItemCollectionMetrics
 }
 
 ///////////////////// Batch Get
@@ -203,8 +229,17 @@ func (b *BatchGet) buildTableMap() BatchGetTableMap {
 		}
 		return
 	}
+	seen := map[string]bool{}
 	for get := b.gets; get != nil; get = get.next {
 		entry := ensure(get.table)
+		// DynamoDB rejects duplicate keys, so drop them when we know the key schema.
+		if meta := b.client.tables.get(get.table); meta != nil {
+			canonical := get.table + "\x00" + meta.primary.canonical(get.item)
+			if seen[canonical] {
+				continue
+			}
+			seen[canonical] = true
+		}
 		entry.Keys = append(entry.Keys, get.item)
 	}
 	for option := b.options; option != nil; option = option.next {
@@ -225,6 +260,11 @@ func (b *BatchGet) buildTableMap() BatchGetTableMap {
 
 // Execute this batch get.
 func (b *BatchGet) Execute() (result *BatchGetResult, err error) {
+	for get := b.gets; get != nil; get = get.next {
+		if err = b.client.ValidateKey(get.table, get.item); err != nil {
+			return
+		}
+	}
 	return b.client.executor.BatchGetItem(b)
 }
 
@@ -234,7 +274,7 @@ func (e *AwsExecutor) BatchGetItem(b *BatchGet) (result *BatchGetResult, err err
 		RequestItems:           b.buildTableMap(),
 		ReturnConsumedCapacity: b.capacityDetail,
 	}
-	err = e.MakeRequestUnmarshal("BatchGetItem", &req, &result)
+	err = e.sendUnmarshal(b, "BatchGetItem", &req, &result)
 	return
 }
 
@@ -7,14 +7,17 @@ type deleteItemRequest struct {
 	ConditionExpression string `json:",omitempty"`
 	expressionAttributes
 
-	// TODO ReturnItemCollectionMetrics
-	ReturnConsumedCapacity CapacityDetail `json:",omitempty"`
-	ReturnValues           ReturnValues   `json:",omitempty"`
+	ReturnConsumedCapacity      CapacityDetail              `json:",omitempty"`
+	ReturnItemCollectionMetrics ItemCollectionMetricsDetail `json:",omitempty"`
+	ReturnValues                ReturnValues                `json:",omitempty"`
+
+	// Only set by version checks
+	ReturnValuesOnConditionCheckFailure ReturnValues `json:",omitempty"`
 }
 
 func newDeleteItem(client *Client, table string, key Document) *DeleteItem {
 	return &DeleteItem{
-		executor: client.executor,
+		client: client,
 		req: deleteItemRequest{
 			TableName: table,
 			Key:       key,
@@ -23,8 +26,9 @@ func newDeleteItem(client *Client, table string, key Document) *DeleteItem {
 }
 
 type DeleteItem struct {
-	executor Executor
-	req      deleteItemRequest
+	client  *Client
+	req     deleteItemRequest
+	version versionOptions
 }
 
 // Set a ConditionExpression to do a conditional DeleteItem.
@@ -39,30 +43,66 @@ func (d DeleteItem) ReturnConsumedCapacity(consumedCapacity CapacityDetail) *Del
 	return &d
 }
 
+// ReturnItemCollectionMetrics enables item collection metrics on this DeleteItem.
+func (d DeleteItem) ReturnItemCollectionMetrics(detail ItemCollectionMetricsDetail) *DeleteItem {
+	d.req.ReturnItemCollectionMetrics = detail
+	return &d
+}
+
 // Set ReturnValues. For DeleteItem, it can only be ReturnAllOld
 func (d DeleteItem) ReturnValues(returnValues ReturnValues) *DeleteItem {
 	d.req.ReturnValues = returnValues
 	return &d
 }
 
+/*
+VersionAttribute turns on optimistic locking for this DeleteItem using the
+given attribute, instead of the one set for the table with SetVersionAttribute.
+*/
+func (d DeleteItem) VersionAttribute(attribute string) *DeleteItem {
+	d.version.attribute = attribute
+	return &d
+}
+
+/*
+ExpectVersion makes this DeleteItem fail with ErrVersionConflict unless the
+item has the given version. Only used when there is a version attribute.
+*/
+func (d DeleteItem) ExpectVersion(version int64) *DeleteItem {
+	d.version.expected = &version
+	return &d
+}
+
 /*
 Actually Execute this putitem.
 
-DeleteItemResult will be nil unless ReturnValues is set.
+DeleteItemResult will be nil unless ReturnValues, ReturnConsumedCapacity or
+ReturnItemCollectionMetrics is set.
 */
 func (d *DeleteItem) Execute() (res *DeleteItemResult, err error) {
-	return d.executor.DeleteItem(d)
+	if err = d.client.ValidateKey(d.req.TableName, d.req.Key); err != nil {
+		return
+	}
+	attribute := d.client.versionAttribute(d.req.TableName, d.version.attribute)
+	if attribute == "" {
+		return d.client.executor.DeleteItem(d)
+	}
+	versioned, check := d.withVersion(attribute)
+	res, err = d.client.executor.DeleteItem(versioned)
+	return res, check.check(err)
 }
 
 func (e *AwsExecutor) DeleteItem(d *DeleteItem) (res *DeleteItemResult, err error) {
-	if d.req.ReturnValues != ReturnNone && d.req.ReturnValues != "" {
-		err = e.MakeRequestUnmarshal("DeleteItem", &d.req, &res)
+	if (d.req.ReturnValues != ReturnNone && d.req.ReturnValues != "") || d.req.ReturnConsumedCapacity != "" || d.req.ReturnItemCollectionMetrics != "" {
+		err = e.sendUnmarshal(d, "DeleteItem", &d.req, &res)
 	} else {
-		_, err = e.makeRequest("DeleteItem", &d.req)
+		_, err = e.send(d, "DeleteItem", &d.req)
 	}
 	return
 }
 
 type DeleteItemResult struct {
-	Attributes Document
+	Attributes            Document
+	ConsumedCapacity      *ConsumedCapacity
+	ItemCollectionMetrics *ItemCollectionMetrics
 }
@@ -62,12 +62,15 @@ func (p GetItem) ConsistentRead(strong bool) *GetItem {
 
 // Execute the get item.
 func (p *GetItem) Execute() (result *GetItemResult, err error) {
+	if err = p.client.ValidateKey(p.req.TableName, p.req.Key); err != nil {
+		return
+	}
 	return p.client.executor.GetItem(p)
 }
 
 // GetItem gets a single item.
 func (e *AwsExecutor) GetItem(g *GetItem) (result *GetItemResult, err error) {
-	err = e.MakeRequestUnmarshal("GetItem", &g.req, &result)
+	err = e.sendUnmarshal(g, "GetItem", &g.req, &result)
 	return
 }
 
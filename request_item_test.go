@@ -14,7 +14,7 @@ func TestDeleteItem(t *testing.T) {
 	assert.Equal(Document{":bar": "baz"}, di.req.ExpressionAttributeValues)
 
 	attrib := Document{"Id": 50, "Foo": "Bar"}
-	mock.DeleteItemResult = &DeleteItemResult{attrib}
+	mock.DeleteItemResult = &DeleteItemResult{Attributes: attrib}
 	result, err := di.Execute()
 	assert.NoError(err)
 	assert.Equal(attrib, result.Attributes)
@@ -105,6 +105,59 @@ func TestQueryReuse(t *testing.T) {
 	check(q2, 2, 45) // check clobbering again
 }
 
+func TestQueryKeyConditions(t *testing.T) {
+	assert, client, mock := setUp(t)
+	mock.QueryResult = &QueryResult{LastEvaluatedKey: HashRangeKey("UserId", 42, "Date", "b")}
+	q := client.Query("Posts").HashKey("UserId", 42)
+
+	ranges := []struct {
+		query      *Query
+		expression string
+	}{
+		{q, "#dynagoHash = :dynagoHash"},
+		{q.RangeGt("Date", "a"), "#dynagoHash = :dynagoHash AND #dynagoRange > :dynagoRange"},
+		{q.RangeGte("Date", "a"), "#dynagoHash = :dynagoHash AND #dynagoRange >= :dynagoRange"},
+		{q.RangeLt("Date", "a"), "#dynagoHash = :dynagoHash AND #dynagoRange < :dynagoRange"},
+		{q.RangeLte("Date", "a"), "#dynagoHash = :dynagoHash AND #dynagoRange <= :dynagoRange"},
+		{q.RangeBeginsWith("Date", "a"), "#dynagoHash = :dynagoHash AND begins_with(#dynagoRange, :dynagoRange)"},
+		{q.RangeGt("Date", "z").RangeBetween("Date", "a", "b"), "#dynagoHash = :dynagoHash AND #dynagoRange BETWEEN :dynagoRange AND :dynagoRange2"},
+	}
+	for _, r := range ranges {
+		_, err := r.query.Execute()
+		assert.NoError(err)
+		assert.Equal(r.expression, mock.QueryCall.KeyConditionExpression)
+	}
+	call := mock.QueryCall
+	assert.Equal(map[string]string{"#dynagoHash": "UserId", "#dynagoRange": "Date"}, call.ExpressionAttributeNames)
+	assert.Equal(Document{":dynagoHash": 42, ":dynagoRange": "a", ":dynagoRange2": "b"}, call.ExpressionAttributeValues)
+	assert.Equal("", q.req.KeyConditionExpression)
+
+	// Placeholders don't clash with params, and are combined with other conditions.
+	result, err := client.Query("Posts").
+		KeyConditionExpression("#dynagoHash = :dynagoHash", P("#dynagoHash", "Other"), P(":dynagoHash", 1)).
+		RangeBeginsWith("Date", "2020-").
+		Execute()
+	assert.NoError(err)
+	call = mock.QueryCall
+	assert.Equal("#dynagoHash = :dynagoHash AND begins_with(#dynagoRange, :dynagoRange)", call.KeyConditionExpression)
+
+	// The next page doesn't add the conditions again.
+	_, err = result.Next().Execute()
+	assert.NoError(err)
+	assert.Equal("#dynagoHash = :dynagoHash AND begins_with(#dynagoRange, :dynagoRange)", mock.QueryCall.KeyConditionExpression)
+	assert.Equal(HashRangeKey("UserId", 42, "Date", "b"), mock.QueryCall.ExclusiveStartKey)
+
+	_, err = client.Query("Posts").
+		HashKey("UserId", 42).
+		Params(P("#dynagoHash", "Other"), P(":dynagoHash", 1)).
+		Execute()
+	assert.NoError(err)
+	call = mock.QueryCall
+	assert.Equal("#dynagoHash2 = :dynagoHash2", call.KeyConditionExpression)
+	assert.Equal(map[string]string{"#dynagoHash": "Other", "#dynagoHash2": "UserId"}, call.ExpressionAttributeNames)
+	assert.Equal(Document{":dynagoHash": 1, ":dynagoHash2": 42}, call.ExpressionAttributeValues)
+}
+
 func TestUpdateItem(t *testing.T) {
 	assert, client, mock := setUp(t)
 	ui := client.UpdateItem("table", HashKey("Id", 1)).
@@ -116,3 +169,58 @@ func TestUpdateItem(t *testing.T) {
 	assert.NoError(err)
 	assert.NotNil(result)
 }
+
+func TestUpdateItemConsumedCapacity(t *testing.T) {
+	assert, _, _ := setUp(t)
+	requester, client := awsSetUp(t)
+	requester.returnBody = []byte(`{"ConsumedCapacity": {"TableName": "table", "CapacityUnits": 2}}`)
+	result, err := client.UpdateItem("table", HashKey("Id", 1)).
+		UpdateExpression("SET Foo = :foo", P(":foo", 1)).
+		ReturnConsumedCapacity(CapacityTotal).
+		Execute()
+	assert.NoError(err)
+	assert.Contains(string(requester.body), `"ReturnConsumedCapacity":"TOTAL"`)
+	assert.Equal(&ConsumedCapacity{TableName: "table", CapacityUnits: 2}, result.ConsumedCapacity)
+
+	deleted, err := client.DeleteItem("table", HashKey("Id", 1)).ReturnConsumedCapacity(CapacityTotal).Execute()
+	assert.NoError(err)
+	assert.Equal(2.0, deleted.ConsumedCapacity.CapacityUnits)
+}
+
+func TestItemCollectionMetrics(t *testing.T) {
+	assert, _, _ := setUp(t)
+	requester, client := awsSetUp(t)
+	requester.returnBody = []byte(`{"ItemCollectionMetrics": {
+		"ItemCollectionKey": {"UserId": {"N": "42"}},
+		"SizeEstimateRangeGB": [1.5, 2.5]
+	}}`)
+	expected := &ItemCollectionMetrics{
+		ItemCollectionKey:   Document{"UserId": Number("42")},
+		SizeEstimateRangeGB: []float64{1.5, 2.5},
+	}
+
+	put, err := client.PutItem("table", Document{"UserId": 42}).ReturnItemCollectionMetrics(ItemCollectionMetricsSize).Execute()
+	assert.NoError(err)
+	assert.Contains(string(requester.body), `"ReturnItemCollectionMetrics":"SIZE"`)
+	assert.Equal(expected, put.ItemCollectionMetrics)
+
+	update, err := client.UpdateItem("table", HashKey("UserId", 42)).ReturnItemCollectionMetrics(ItemCollectionMetricsSize).Execute()
+	assert.NoError(err)
+	assert.Equal(expected, update.ItemCollectionMetrics)
+
+	deleted, err := client.DeleteItem("table", HashKey("UserId", 42)).ReturnItemCollectionMetrics(ItemCollectionMetricsSize).Execute()
+	assert.NoError(err)
+	assert.Equal(expected, deleted.ItemCollectionMetrics)
+
+	requester.returnBody = []byte(`{"ItemCollectionMetrics": {"table": [
+		{"ItemCollectionKey": {"UserId": {"N": "42"}}, "SizeEstimateRangeGB": [1.5, 2.5]}
+	]}}`)
+	batch, err := client.BatchWrite().Put("table", Document{"UserId": 42}).ReturnItemCollectionMetrics(ItemCollectionMetricsSize).Execute()
+	assert.NoError(err)
+	assert.Contains(string(requester.body), `"ReturnItemCollectionMetrics":"SIZE"`)
+	assert.Equal(BatchItemCollectionMetrics{"table": {*expected}}, batch.ItemCollectionMetrics)
+
+	// Not requested, not sent
+	client.PutItem("table", Document{"UserId": 42}).Execute()
+	assert.NotContains(string(requester.body), "ReturnItemCollectionMetrics")
+}
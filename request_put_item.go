@@ -7,9 +7,12 @@ type putItemRequest struct {
 	ConditionExpression string `json:",omitempty"`
 	expressionAttributes
 
-	// TODO ReturnItemCollectionMetrics
-	ReturnConsumedCapacity CapacityDetail `json:",omitempty"`
-	ReturnValues           ReturnValues   `json:",omitempty"`
+	ReturnConsumedCapacity      CapacityDetail              `json:",omitempty"`
+	ReturnItemCollectionMetrics ItemCollectionMetricsDetail `json:",omitempty"`
+	ReturnValues                ReturnValues                `json:",omitempty"`
+
+	// Only set by version checks
+	ReturnValuesOnConditionCheckFailure ReturnValues `json:",omitempty"`
 }
 
 func newPutItem(client *Client, table string, item Document) *PutItem {
@@ -24,8 +27,9 @@ func newPutItem(client *Client, table string, item Document) *PutItem {
 
 // PutItem is used to create/replace single items in the table.
 type PutItem struct {
-	client *Client
-	req    putItemRequest
+	client  *Client
+	req     putItemRequest
+	version versionOptions
 }
 
 // ConditionExpression sets a condition which if not satisfied, the PutItem is not performed.
@@ -63,33 +67,59 @@ func (p PutItem) ReturnConsumedCapacity(consumedCapacity CapacityDetail) *PutIte
 	return &p
 }
 
+// ReturnItemCollectionMetrics enables item collection metrics on this PutItem.
+func (p PutItem) ReturnItemCollectionMetrics(detail ItemCollectionMetricsDetail) *PutItem {
+	p.req.ReturnItemCollectionMetrics = detail
+	return &p
+}
+
 // ReturnValues can allow you to ask for either previous or new values on an update
 func (p PutItem) ReturnValues(returnValues ReturnValues) *PutItem {
 	p.req.ReturnValues = returnValues
 	return &p
 }
 
+/*
+VersionAttribute turns on optimistic locking for this PutItem using the given
+attribute, instead of the one set for the table with SetVersionAttribute.
+*/
+func (p PutItem) VersionAttribute(attribute string) *PutItem {
+	p.version.attribute = attribute
+	return &p
+}
+
 /*
 Execute this PutItem.
 
-PutItemResult will be nil unless ReturnValues or ReturnConsumedCapacity is set.
+PutItemResult will be nil unless ReturnValues, ReturnConsumedCapacity or
+ReturnItemCollectionMetrics is set.
 */
 func (p *PutItem) Execute() (res *PutItemResult, err error) {
-	return p.client.executor.PutItem(p)
+	attribute := p.client.versionAttribute(p.req.TableName, p.version.attribute)
+	if attribute == "" {
+		return p.client.executor.PutItem(p)
+	}
+	versioned, check, err := p.withVersion(attribute)
+	if err != nil {
+		return
+	}
+	res, err = p.client.executor.PutItem(versioned)
+	return res, check.check(err)
 }
 
 // PutItem on this executor.
 func (e *AwsExecutor) PutItem(p *PutItem) (res *PutItemResult, err error) {
-	if (p.req.ReturnValues != ReturnNone && p.req.ReturnValues != "") || p.req.ReturnConsumedCapacity != "" {
-		err = e.MakeRequestUnmarshal("PutItem", &p.req, &res)
+	if (p.req.ReturnValues != ReturnNone && p.req.ReturnValues != "") || p.req.ReturnConsumedCapacity != "" || p.req.ReturnItemCollectionMetrics != "" {
+		err = e.sendUnmarshal(p, "PutItem", &p.req, &res)
 	} else {
-		_, err = e.makeRequest("PutItem", &p.req)
+		_, err = e.send(p, "PutItem", &p.req)
 	}
 	return
 }
 
 // PutItemResult is returned when a PutItem is executed.
 type PutItemResult struct {
-	Attributes       Document
-	ConsumedCapacity *ConsumedCapacity
+	Attributes            Document
+	ConsumedCapacity      *ConsumedCapacity
+	ItemCollectionMetrics *ItemCollectionMetrics
 }
@@ -1,5 +1,7 @@
 package dynago
 
+import "strings"
+
 type queryRequest struct {
 	TableName string
 	IndexName string `json:",omitempty"`
@@ -25,13 +27,14 @@ func newQuery(client *Client, table string) *Query {
 	req := queryRequest{
 		TableName: table,
 	}
-	return &Query{client, req}
+	return &Query{client: client, req: req}
 }
 
 // Query is used to return items in the same hash key.
 type Query struct {
 	client *Client
 	req    queryRequest
+	keys   keyConditions
 }
 
 // IndexName specifies to query via a secondary index instead of the table's primary key.
@@ -60,6 +63,59 @@ func (q Query) KeyConditionExpression(expression string, params ...Params) *Quer
 	return &q
 }
 
+/*
+HashKey sets a key condition for items with the given hash key value, instead of
+writing it in KeyConditionExpression:
+
+	query := client.Query("Posts").
+		HashKey("UserId", 42).
+		RangeBeginsWith("Date", "2020-")
+
+The expression and its placeholders are made when the query is executed, with
+placeholders which don't clash with any set with Params. Any key condition set
+with KeyConditionExpression is combined with AND.
+*/
+func (q Query) HashKey(name string, value interface{}) *Query {
+	q.keys.hashName, q.keys.hashValue = name, value
+	return &q
+}
+
+// RangeBetween sets a key condition for range key values from low to high, inclusive.
+func (q Query) RangeBetween(name string, low, high interface{}) *Query {
+	return q.rangeKey(name, "BETWEEN", low, high)
+}
+
+// RangeBeginsWith sets a key condition for range key values starting with prefix.
+func (q Query) RangeBeginsWith(name string, prefix interface{}) *Query {
+	return q.rangeKey(name, "begins_with", prefix)
+}
+
+// RangeGt sets a key condition for range key values greater than value.
+func (q Query) RangeGt(name string, value interface{}) *Query {
+	return q.rangeKey(name, ">", value)
+}
+
+// RangeGte sets a key condition for range key values greater than or equal to value.
+func (q Query) RangeGte(name string, value interface{}) *Query {
+	return q.rangeKey(name, ">=", value)
+}
+
+// RangeLt sets a key condition for range key values less than value.
+func (q Query) RangeLt(name string, value interface{}) *Query {
+	return q.rangeKey(name, "<", value)
+}
+
+// RangeLte sets a key condition for range key values less than or equal to value.
+func (q Query) RangeLte(name string, value interface{}) *Query {
+	return q.rangeKey(name, "<=", value)
+}
+
+// Sets the range key condition, replacing any set before.
+func (q Query) rangeKey(name, op string, values ...interface{}) *Query {
+	q.keys.rangeName, q.keys.rangeOp, q.keys.rangeValues = name, op, values
+	return &q
+}
+
 // ProjectionExpression allows the client to specify which attributes are returned.
 func (q Query) ProjectionExpression(expression string, params ...Params) *Query {
 	q.req.paramsHelper(params)
@@ -136,12 +192,56 @@ func (q Query) ExclusiveStartKey(key Document) *Query {
 
 // Execute this query and return results.
 func (q *Query) Execute() (result *QueryResult, err error) {
-	return q.client.executor.Query(q)
+	return q.client.executor.Query(q.withKeyConditions())
+}
+
+// keyConditions are the key conditions set with HashKey and the Range methods.
+type keyConditions struct {
+	hashName    string
+	hashValue   interface{}
+	rangeName   string
+	rangeOp     string
+	rangeValues []interface{}
+}
+
+// A copy of the query with the key conditions added to the KeyConditionExpression.
+func (q *Query) withKeyConditions() *Query {
+	k := q.keys
+	if k.hashName == "" && k.rangeName == "" {
+		return q
+	}
+	c := *q
+	c.keys = keyConditions{}
+	var conditions []string
+	if c.req.KeyConditionExpression != "" {
+		conditions = append(conditions, c.req.KeyConditionExpression)
+	}
+	if k.hashName != "" {
+		name, value := c.req.placeholder("#dynagoHash", k.hashName), c.req.placeholder(":dynagoHash", k.hashValue)
+		conditions = append(conditions, name+" = "+value)
+	}
+	if k.rangeName != "" {
+		name := c.req.placeholder("#dynagoRange", k.rangeName)
+		values := make([]string, len(k.rangeValues))
+		for i, v := range k.rangeValues {
+			values[i] = c.req.placeholder(":dynagoRange", v)
+		}
+		switch k.rangeOp {
+		case "BETWEEN":
+			conditions = append(conditions, name+" BETWEEN "+values[0]+" AND "+values[1])
+		case "begins_with":
+			conditions = append(conditions, "begins_with("+name+", "+values[0]+")")
+		default:
+			conditions = append(conditions, name+" "+k.rangeOp+" "+values[0])
+		}
+	}
+	c.req.KeyConditionExpression = strings.Join(conditions, " AND ")
+	return &c
 }
 
 // Query execution logic
 func (e *AwsExecutor) Query(q *Query) (result *QueryResult, err error) {
-	err = e.MakeRequestUnmarshal("Query", &q.req, &result)
+	err = e.sendUnmarshal(q, "Query", &q.req, &result)
 	if err == nil {
 		result.query = q
 	}
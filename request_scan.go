@@ -88,7 +88,7 @@ func (s *Scan) Execute() (*ScanResult, error) {
 // Scan operation
 func (e *AwsExecutor) Scan(s *Scan) (result *ScanResult, err error) {
 	result = &ScanResult{req: s}
-	err = e.MakeRequestUnmarshal("Scan", s.req, &result)
+	err = e.sendUnmarshal(s, "Scan", s.req, &result)
 	return
 }
 
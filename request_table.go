@@ -9,17 +9,32 @@ type awsSchemaExecutor struct {
 }
 
 func (e awsSchemaExecutor) CreateTable(req *schema.CreateRequest) (resp *schema.CreateResult, err error) {
-	err = e.MakeRequestUnmarshal("CreateTable", req, &resp)
+	err = e.sendUnmarshal(req, "CreateTable", req, &resp)
 	return
 }
 
 func (e awsSchemaExecutor) DeleteTable(req *schema.DeleteRequest) (resp *schema.DeleteResult, err error) {
-	err = e.MakeRequestUnmarshal("DeleteTable", req, &resp)
+	err = e.sendUnmarshal(req, "DeleteTable", req, &resp)
 	return
 }
 
 func (e awsSchemaExecutor) DescribeTable(req *schema.DescribeRequest) (resp *schema.DescribeResponse, err error) {
-	err = e.MakeRequestUnmarshal("DescribeTable", req, &resp)
+	err = e.sendUnmarshal(req, "DescribeTable", req, &resp)
+	return
+}
+
+func (e awsSchemaExecutor) UpdateTable(req *schema.UpdateRequest) (resp *schema.UpdateResult, err error) {
+	err = e.sendUnmarshal(req, "UpdateTable", req, &resp)
+	return
+}
+
+func (e awsSchemaExecutor) UpdateTimeToLive(req *schema.UpdateTTLRequest) (resp *schema.UpdateTTLResult, err error) {
+	err = e.sendUnmarshal(req, "UpdateTimeToLive", req, &resp)
+	return
+}
+
+func (e awsSchemaExecutor) DescribeTimeToLive(req *schema.DescribeTTLRequest) (resp *schema.DescribeTTLResult, err error) {
+	err = e.sendUnmarshal(req, "DescribeTimeToLive", req, &resp)
 	return
 }
 
@@ -45,7 +60,7 @@ func (l *ListTables) Execute() (result *ListTablesResult, err error) {
 }
 
 func (e awsSchemaExecutor) ListTables(list *ListTables) (resp *schema.ListResponse, err error) {
-	err = e.MakeRequestUnmarshal("ListTables", list.req, &resp)
+	err = e.sendUnmarshal(list, "ListTables", list.req, &resp)
 	return resp, err
 }
 
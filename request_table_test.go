@@ -0,0 +1,151 @@
+package dynago
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
+type fakeRequester struct {
+	target      string
+	body        []byte
+	returnBody  []byte
+	returnError error
+}
+
+func (r *fakeRequester) MakeRequest(target string, body []byte) ([]byte, error) {
+	r.target = target
+	r.body = body
+	return r.returnBody, r.returnError
+}
+
+func awsSetUp(t *testing.T) (*fakeRequester, *Client) {
+	requester := &fakeRequester{}
+	return requester, NewClient(&AwsExecutor{requester})
+}
+
+func TestUpdateTableEncode(t *testing.T) {
+	assert, _, _ := setUp(t)
+	requester, client := awsSetUp(t)
+	requester.returnBody = []byte(`{"TableDescription": {"TableName": "Posts", "TableStatus": "UPDATING"}}`)
+
+	index := schema.SecondaryIndex{
+		IndexName:  "ByTitle",
+		KeySchema:  []schema.KeySchema{{AttributeName: "Title", KeyType: schema.HashKey}},
+		Projection: schema.NewProjection(schema.ProjectKeysOnly),
+	}
+	req := schema.NewUpdateRequest("Posts").
+		Billing(schema.BillingPayPerRequest).
+		CreateGlobalIndex(index, schema.AttributeDefinition{AttributeName: "Title", AttributeType: schema.String}).
+		DeleteGlobalIndex("Old").
+		DisableStream()
+	result, err := client.UpdateTable(req)
+	assert.NoError(err)
+	assert.Equal("UPDATING", result.TableDescription.TableStatus)
+	assert.Equal("UpdateTable", requester.target)
+	assert.Equal(
+		`{"TableName":"Posts",`+
+			`"AttributeDefinitions":[{"AttributeName":"Title","AttributeType":"S"}],`+
+			`"BillingMode":"PAY_PER_REQUEST",`+
+			`"GlobalSecondaryIndexUpdates":[`+
+			`{"Create":{"IndexName":"ByTitle","KeySchema":[{"AttributeName":"Title","KeyType":"HASH"}],"Projection":{"ProjectionType":"KEYS_ONLY"}}},`+
+			`{"Delete":{"IndexName":"Old"}}],`+
+			`"StreamSpecification":{"StreamEnabled":false}}`,
+		string(requester.body),
+	)
+
+	_, err = client.UpdateTable(schema.NewUpdateRequest("Posts").
+		Throughput(4, 5).
+		UpdateGlobalIndex("ByTitle", schema.NewThroughput(2, 3)).
+		EnableStream(schema.StreamNewImage))
+	assert.NoError(err)
+	assert.Equal(
+		`{"TableName":"Posts",`+
+			`"ProvisionedThroughput":{"ReadCapacityUnits":4,"WriteCapacityUnits":5},`+
+			`"GlobalSecondaryIndexUpdates":[`+
+			`{"Update":{"IndexName":"ByTitle","ProvisionedThroughput":{"ReadCapacityUnits":2,"WriteCapacityUnits":3}}}],`+
+			`"StreamSpecification":{"StreamEnabled":true,"StreamViewType":"NEW_IMAGE"}}`,
+		string(requester.body),
+	)
+}
+
+func TestCreateTableEncode(t *testing.T) {
+	assert, _, _ := setUp(t)
+	requester, client := awsSetUp(t)
+	requester.returnBody = []byte(`{"TableDescription": {"TableName": "Posts"}}`)
+
+	req := schema.NewCreateRequest("Posts").
+		HashKey("UserId", schema.Number).
+		RangeKey("Dated", schema.Number).
+		GlobalSecondaryIndex("ByTitle", schema.NewAttribute("Title", schema.String), schema.NewAttribute("Dated", schema.Number),
+			schema.NewProjection(schema.ProjectKeysOnly), schema.NewThroughput(2, 3)).
+		LocalSecondaryIndex("ByScore", schema.NewAttribute("Score", schema.Number), schema.NewProjection(schema.ProjectAll)).
+		SSE(schema.SSEKMS, "").
+		Tag("team", "posts").
+		AddTags(map[string]string{"env": "prod", "cost": "blog"})
+	_, err := client.CreateTable(req)
+	assert.NoError(err)
+	assert.Equal("CreateTable", requester.target)
+	assert.Equal(
+		`{"TableName":"Posts",`+
+			`"AttributeDefinitions":[{"AttributeName":"UserId","AttributeType":"N"},{"AttributeName":"Dated","AttributeType":"N"},`+
+			`{"AttributeName":"Title","AttributeType":"S"},{"AttributeName":"Score","AttributeType":"N"}],`+
+			`"KeySchema":[{"AttributeName":"UserId","KeyType":"HASH"},{"AttributeName":"Dated","KeyType":"RANGE"}],`+
+			`"ProvisionedThroughput":{"ReadCapacityUnits":1,"WriteCapacityUnits":1},`+
+			`"GlobalSecondaryIndexes":[{"IndexName":"ByTitle",`+
+			`"KeySchema":[{"AttributeName":"Title","KeyType":"HASH"},{"AttributeName":"Dated","KeyType":"RANGE"}],`+
+			`"Projection":{"ProjectionType":"KEYS_ONLY"},"ProvisionedThroughput":{"ReadCapacityUnits":2,"WriteCapacityUnits":3}}],`+
+			`"SSESpecification":{"Enabled":true,"SSEType":"KMS"},`+
+			`"Tags":[{"Key":"team","Value":"posts"},{"Key":"cost","Value":"blog"},{"Key":"env","Value":"prod"}],`+
+			`"LocalSecondaryIndexes":[{"IndexName":"ByScore",`+
+			`"KeySchema":[{"AttributeName":"UserId","KeyType":"HASH"},{"AttributeName":"Score","KeyType":"RANGE"}],`+
+			`"Projection":{"ProjectionType":"ALL"}}]}`,
+		string(requester.body),
+	)
+
+	_, err = client.CreateTable(req.OnDemand())
+	assert.NoError(err)
+	var decoded map[string]interface{}
+	assert.NoError(json.Unmarshal(requester.body, &decoded))
+	assert.Equal("PAY_PER_REQUEST", decoded["BillingMode"])
+	assert.Nil(decoded["ProvisionedThroughput"])
+	gsi := decoded["GlobalSecondaryIndexes"].([]interface{})[0].(map[string]interface{})
+	assert.Equal("ByTitle", gsi["IndexName"])
+	assert.Nil(gsi["ProvisionedThroughput"])
+
+	// The hash key can be given after the local indexes.
+	req = schema.NewCreateRequest("Foo").
+		LocalSecondaryIndex("ByScore", schema.NewAttribute("Score", schema.Number), schema.NewProjection(schema.ProjectAll)).
+		HashKey("UserId", schema.Number)
+	_, err = client.CreateTable(req)
+	assert.NoError(err)
+	assert.Contains(string(requester.body), `"LocalSecondaryIndexes":[{"IndexName":"ByScore",`+
+		`"KeySchema":[{"AttributeName":"UserId","KeyType":"HASH"},{"AttributeName":"Score","KeyType":"RANGE"}]`)
+
+	// Or set without the builders.
+	req = schema.NewCreateRequest("Foo").
+		LocalSecondaryIndex("ByScore", schema.NewAttribute("Score", schema.Number), schema.NewProjection(schema.ProjectAll))
+	req.KeySchema = []schema.KeySchema{{AttributeName: "UserId", KeyType: schema.HashKey}}
+	_, err = client.CreateTable(req)
+	assert.NoError(err)
+	assert.Contains(string(requester.body), `"KeySchema":[{"AttributeName":"UserId","KeyType":"HASH"},{"AttributeName":"Score","KeyType":"RANGE"}]`)
+}
+
+func TestTimeToLive(t *testing.T) {
+	assert, _, _ := setUp(t)
+	requester, client := awsSetUp(t)
+	requester.returnBody = []byte(`{"TimeToLiveSpecification": {"AttributeName": "Expires", "Enabled": true}}`)
+	result, err := client.UpdateTimeToLive("Sessions", "Expires", true)
+	assert.NoError(err)
+	assert.Equal("UpdateTimeToLive", requester.target)
+	assert.Equal(`{"TableName":"Sessions","TimeToLiveSpecification":{"AttributeName":"Expires","Enabled":true}}`, string(requester.body))
+	assert.Equal(true, result.TimeToLiveSpecification.Enabled)
+
+	requester.returnBody = []byte(`{"TimeToLiveDescription": {"AttributeName": "Expires", "TimeToLiveStatus": "ENABLED"}}`)
+	desc, err := client.DescribeTimeToLive("Sessions")
+	assert.NoError(err)
+	assert.Equal("DescribeTimeToLive", requester.target)
+	assert.Equal(`{"TableName":"Sessions"}`, string(requester.body))
+	assert.Equal("ENABLED", desc.TimeToLiveDescription.TimeToLiveStatus)
+}
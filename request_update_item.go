@@ -8,9 +8,12 @@ type updateItemRequest struct {
 	UpdateExpression    string `json:",omitempty"`
 	expressionAttributes
 
-	ReturnConsumedCapacity      string       `json:",omitempty"` // TODO
-	ReturnItemCollectionMetrics string       `json:",omitempty"` // TODO
-	ReturnValues                ReturnValues `json:",omitempty"`
+	ReturnConsumedCapacity      CapacityDetail              `json:",omitempty"`
+	ReturnItemCollectionMetrics ItemCollectionMetricsDetail `json:",omitempty"`
+	ReturnValues                ReturnValues                `json:",omitempty"`
+
+	// Only set by version checks
+	ReturnValuesOnConditionCheckFailure ReturnValues `json:",omitempty"`
 }
 
 func newUpdateItem(client *Client, table string, key Document) *UpdateItem {
@@ -25,8 +28,9 @@ func newUpdateItem(client *Client, table string, key Document) *UpdateItem {
 
 // UpdateItem is used to modify a single item in a table.
 type UpdateItem struct {
-	client *Client
-	req    updateItemRequest
+	client  *Client
+	req     updateItemRequest
+	version versionOptions
 }
 
 // ConditionExpression sets a condition which if not satisfied, the PutItem is not performed.
@@ -82,22 +86,69 @@ func (u UpdateItem) ReturnValues(returnValues ReturnValues) *UpdateItem {
 	return &u
 }
 
-// Execute this UpdateItem and return the result.
+// ReturnConsumedCapacity enables capacity reporting on this UpdateItem.
+func (u UpdateItem) ReturnConsumedCapacity(consumedCapacity CapacityDetail) *UpdateItem {
+	u.req.ReturnConsumedCapacity = consumedCapacity
+	return &u
+}
+
+// ReturnItemCollectionMetrics enables item collection metrics on this UpdateItem.
+func (u UpdateItem) ReturnItemCollectionMetrics(detail ItemCollectionMetricsDetail) *UpdateItem {
+	u.req.ReturnItemCollectionMetrics = detail
+	return &u
+}
+
+/*
+VersionAttribute turns on optimistic locking for this UpdateItem using the
+given attribute, instead of the one set for the table with SetVersionAttribute.
+*/
+func (u UpdateItem) VersionAttribute(attribute string) *UpdateItem {
+	u.version.attribute = attribute
+	return &u
+}
+
+/*
+ExpectVersion makes this UpdateItem fail with ErrVersionConflict unless the
+item has the given version, or doesn't exist if version is zero.
+Only used when there is a version attribute.
+*/
+func (u UpdateItem) ExpectVersion(version int64) *UpdateItem {
+	u.version.expected = &version
+	return &u
+}
+
+/*
+Execute this UpdateItem and return the result.
+
+UpdateItemResult will be nil unless ReturnValues, ReturnConsumedCapacity or
+ReturnItemCollectionMetrics is set.
+*/
 func (u *UpdateItem) Execute() (res *UpdateItemResult, err error) {
-	return u.client.executor.UpdateItem(u)
+	if err = u.client.ValidateKey(u.req.TableName, u.req.Key); err != nil {
+		return
+	}
+	attribute := u.client.versionAttribute(u.req.TableName, u.version.attribute)
+	if attribute == "" {
+		return u.client.executor.UpdateItem(u)
+	}
+	versioned, check := u.withVersion(attribute)
+	res, err = u.client.executor.UpdateItem(versioned)
+	return res, check.check(err)
 }
 
 // UpdateItem on this executor.
 func (e *AwsExecutor) UpdateItem(u *UpdateItem) (result *UpdateItemResult, err error) {
-	if u.req.ReturnValues != ReturnNone && u.req.ReturnValues != "" {
-		err = e.MakeRequestUnmarshal("UpdateItem", &u.req, &result)
+	if (u.req.ReturnValues != ReturnNone && u.req.ReturnValues != "") || u.req.ReturnConsumedCapacity != "" || u.req.ReturnItemCollectionMetrics != "" {
+		err = e.sendUnmarshal(u, "UpdateItem", &u.req, &result)
 	} else {
-		_, err = e.makeRequest("UpdateItem", &u.req)
+		_, err = e.send(u, "UpdateItem", &u.req)
 	}
 	return
 }
 
-// UpdateItemResult is returned when ReturnValues is set on the UpdateItem.
+// UpdateItemResult is returned when ReturnValues, ReturnConsumedCapacity or ReturnItemCollectionMetrics is set on the UpdateItem.
 type UpdateItemResult struct {
-	Attributes Document
+	Attributes            Document
+	ConsumedCapacity      *ConsumedCapacity
+	ItemCollectionMetrics *ItemCollectionMetrics
 }
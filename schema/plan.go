@@ -0,0 +1,283 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// PlanStep is a single schema change; exactly one of Create or Update is set.
+type PlanStep struct {
+	Description string
+	Create      *CreateRequest
+	Update      *UpdateRequest
+}
+
+/*
+Plan is an ordered list of changes which reconcile an existing table with a
+desired table definition.
+
+Each step is meant to be applied on its own, waiting for the table and its
+indexes to become ACTIVE in between, since DynamoDB only allows one global
+secondary index to be created or deleted per UpdateTable.
+
+Some differences, like changes to the key schema or local secondary indexes,
+cannot be made on an existing table. These are listed in Recreate and are not
+part of Steps.
+*/
+type Plan struct {
+	TableName string
+	Steps     []PlanStep
+	Recreate  []string // Reasons the table would need to be recreated
+}
+
+/*
+NewPlan compares a desired table definition with the description of the
+existing table and works out the steps to get from one to the other.
+
+current should be nil if the table does not exist yet, in which case the plan
+is to create the table.
+
+An error is returned if a new global secondary index can't be created as
+defined, because a key attribute has no attribute definition or the index has
+no throughput on a provisioned table. Indexes without throughput of their own
+on a provisioned table are given the table's throughput.
+*/
+func NewPlan(desired *CreateRequest, current *TableDescription) (*Plan, error) {
+	p := &Plan{TableName: desired.TableName}
+	if current == nil {
+		p.add(fmt.Sprintf("create table %q", desired.TableName), desired, nil)
+		return p, nil
+	}
+
+	if !keySchemaEqual(desired.KeySchema, current.KeySchema) {
+		p.Recreate = append(p.Recreate, fmt.Sprintf(
+			"key schema changed from %s to %s",
+			formatKeySchema(current.KeySchema), formatKeySchema(desired.KeySchema),
+		))
+	}
+	for _, k := range desired.KeySchema {
+		want, have := attributeType(desired.AttributeDefinitions, k.AttributeName), attributeType(current.AttributeDefinitions, k.AttributeName)
+		if have != "" && want != have {
+			p.Recreate = append(p.Recreate, fmt.Sprintf("key attribute %s changed type from %s to %s", k.AttributeName, have, want))
+		}
+	}
+	p.diffLocalIndexes(desired.localIndexes(), current.LocalSecondaryIndexes)
+
+	// Deletes go first, so that an index being redefined can be recreated later.
+	currentGlobal := indexesByName(current.GlobalSecondaryIndexes)
+	var creates []SecondaryIndex
+	for _, index := range desired.GlobalSecondaryIndexes {
+		existing, ok := currentGlobal[index.IndexName]
+		if ok && indexEqual(index, existing.SecondaryIndex) {
+			continue
+		}
+		if ok {
+			p.add(fmt.Sprintf("delete global secondary index %q (definition changed)", index.IndexName), nil,
+				NewUpdateRequest(p.TableName).DeleteGlobalIndex(index.IndexName))
+		}
+		creates = append(creates, index)
+	}
+	for _, index := range current.GlobalSecondaryIndexes {
+		if findIndex(desired.GlobalSecondaryIndexes, index.IndexName) == nil {
+			p.add(fmt.Sprintf("delete global secondary index %q", index.IndexName), nil,
+				NewUpdateRequest(p.TableName).DeleteGlobalIndex(index.IndexName))
+		}
+	}
+
+	desiredMode, currentMode := desired.BillingMode, BillingProvisioned
+	if desiredMode == "" {
+		desiredMode = BillingProvisioned
+	}
+	if current.BillingModeSummary != nil && current.BillingModeSummary.BillingMode != "" {
+		currentMode = current.BillingModeSummary.BillingMode
+	}
+	switch t := desired.ProvisionedThroughput; {
+	case desiredMode != currentMode:
+		req := NewUpdateRequest(p.TableName).Billing(desiredMode)
+		if desiredMode == BillingProvisioned {
+			// Switching to provisioned needs throughput for the table and every remaining index.
+			req.Throughput(t.ReadCapacityUnits, t.WriteCapacityUnits)
+			for _, index := range desired.GlobalSecondaryIndexes {
+				if existing, ok := currentGlobal[index.IndexName]; ok && indexEqual(index, existing.SecondaryIndex) {
+					req.UpdateGlobalIndex(index.IndexName, index.ProvisionedThroughput)
+				}
+			}
+		}
+		p.add(fmt.Sprintf("switch billing mode to %s", desiredMode), nil, req)
+	case desiredMode == BillingProvisioned:
+		if t != current.ProvisionedThroughput.ProvisionedThroughput {
+			p.add(fmt.Sprintf("change table throughput to %s", formatThroughput(t)), nil,
+				NewUpdateRequest(p.TableName).Throughput(t.ReadCapacityUnits, t.WriteCapacityUnits))
+		}
+		for _, index := range desired.GlobalSecondaryIndexes {
+			existing, ok := currentGlobal[index.IndexName]
+			if !ok || !indexEqual(index, existing.SecondaryIndex) {
+				continue
+			}
+			if t := index.ProvisionedThroughput; t != existing.ProvisionedThroughput {
+				p.add(fmt.Sprintf("change throughput of global secondary index %q to %s", index.IndexName, formatThroughput(t)), nil,
+					NewUpdateRequest(p.TableName).UpdateGlobalIndex(index.IndexName, t))
+			}
+		}
+	}
+
+	for _, index := range creates {
+		switch {
+		case desiredMode == BillingPayPerRequest:
+			index.ProvisionedThroughput = ProvisionedThroughput{}
+		case index.ProvisionedThroughput == (ProvisionedThroughput{}):
+			index.ProvisionedThroughput = desired.ProvisionedThroughput
+			if index.ProvisionedThroughput == (ProvisionedThroughput{}) {
+				return nil, fmt.Errorf("schema: global secondary index %q needs provisioned throughput", index.IndexName)
+			}
+		}
+		req := NewUpdateRequest(p.TableName)
+		for _, k := range index.KeySchema {
+			t := attributeType(desired.AttributeDefinitions, k.AttributeName)
+			if t == "" {
+				return nil, fmt.Errorf("schema: key attribute %s of global secondary index %q has no attribute definition", k.AttributeName, index.IndexName)
+			}
+			req.ensureAttribute(AttributeDefinition{k.AttributeName, t})
+		}
+		p.add(fmt.Sprintf("create global secondary index %q", index.IndexName), nil, req.CreateGlobalIndex(index))
+	}
+
+	p.diffStream(desired.StreamSpecification, current.StreamSpecification)
+	return p, nil
+}
+
+func (p *Plan) add(description string, create *CreateRequest, update *UpdateRequest) {
+	p.Steps = append(p.Steps, PlanStep{description, create, update})
+}
+
+func (p *Plan) diffLocalIndexes(desired []SecondaryIndex, current []SecondaryIndexResponse) {
+	currentLocal := indexesByName(current)
+	for _, index := range desired {
+		if existing, ok := currentLocal[index.IndexName]; !ok {
+			p.Recreate = append(p.Recreate, fmt.Sprintf("local secondary index %q added", index.IndexName))
+		} else if !indexEqual(index, existing.SecondaryIndex) {
+			p.Recreate = append(p.Recreate, fmt.Sprintf("local secondary index %q changed", index.IndexName))
+		}
+	}
+	for _, index := range current {
+		if findIndex(desired, index.IndexName) == nil {
+			p.Recreate = append(p.Recreate, fmt.Sprintf("local secondary index %q removed", index.IndexName))
+		}
+	}
+}
+
+func (p *Plan) diffStream(desired, current *StreamSpecification) {
+	want, have := desired != nil && desired.StreamEnabled, current != nil && current.StreamEnabled
+	switch {
+	case want && have && desired.StreamViewType != current.StreamViewType:
+		p.add("disable stream (view type changed)", nil, NewUpdateRequest(p.TableName).DisableStream())
+		fallthrough
+	case want && !have:
+		p.add(fmt.Sprintf("enable stream (%s)", desired.StreamViewType), nil,
+			NewUpdateRequest(p.TableName).EnableStream(desired.StreamViewType))
+	case !want && have:
+		p.add("disable stream", nil, NewUpdateRequest(p.TableName).DisableStream())
+	}
+}
+
+// Empty is true if the table already matches the desired definition.
+func (p *Plan) Empty() bool {
+	return len(p.Steps) == 0 && len(p.Recreate) == 0
+}
+
+// RequiresRecreate is true if some changes cannot be made without recreating the table.
+func (p *Plan) RequiresRecreate() bool {
+	return len(p.Recreate) > 0
+}
+
+// String formats the plan for human review.
+func (p *Plan) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Plan for table %q:\n", p.TableName)
+	if p.Empty() {
+		buf.WriteString("  no changes\n")
+	}
+	for i, step := range p.Steps {
+		fmt.Fprintf(&buf, "  %d. %s\n", i+1, step.Description)
+	}
+	for _, reason := range p.Recreate {
+		fmt.Fprintf(&buf, "  ! requires recreating table: %s\n", reason)
+	}
+	return buf.String()
+}
+
+func indexesByName(indexes []SecondaryIndexResponse) map[string]SecondaryIndexResponse {
+	m := make(map[string]SecondaryIndexResponse, len(indexes))
+	for _, index := range indexes {
+		m[index.IndexName] = index
+	}
+	return m
+}
+
+func findIndex(indexes []SecondaryIndex, name string) *SecondaryIndex {
+	for i := range indexes {
+		if indexes[i].IndexName == name {
+			return &indexes[i]
+		}
+	}
+	return nil
+}
+
+// indexEqual compares the parts of an index definition which can't be updated in place.
+func indexEqual(a, b SecondaryIndex) bool {
+	if !keySchemaEqual(a.KeySchema, b.KeySchema) || a.Projection.ProjectionType != b.Projection.ProjectionType {
+		return false
+	}
+	if len(a.Projection.NonKeyAttributes) != len(b.Projection.NonKeyAttributes) {
+		return false
+	}
+	x := append([]string(nil), a.Projection.NonKeyAttributes...)
+	y := append([]string(nil), b.Projection.NonKeyAttributes...)
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func keySchemaEqual(a, b []KeySchema) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func attributeType(definitions []AttributeDefinition, name string) AttributeType {
+	for _, a := range definitions {
+		if a.AttributeName == name {
+			return a.AttributeType
+		}
+	}
+	return ""
+}
+
+func formatKeySchema(keys []KeySchema) string {
+	var buf bytes.Buffer
+	buf.WriteByte('(')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%s %s", k.AttributeName, k.KeyType)
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+func formatThroughput(t ProvisionedThroughput) string {
+	return fmt.Sprintf("%d read / %d write", t.ReadCapacityUnits, t.WriteCapacityUnits)
+}
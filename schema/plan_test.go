@@ -0,0 +1,207 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/rmfarrell/dynago/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func postsTable() *schema.CreateRequest {
+	req := schema.NewCreateRequest("Posts").
+		HashKey("UserId", schema.Number).
+		RangeKey("Dated", schema.Number)
+	req.AttributeDefinitions = append(req.AttributeDefinitions, schema.AttributeDefinition{AttributeName: "Title", AttributeType: schema.String})
+	req.GlobalSecondaryIndexes = []schema.SecondaryIndex{{
+		IndexName:             "ByTitle",
+		KeySchema:             []schema.KeySchema{{AttributeName: "Title", KeyType: schema.HashKey}},
+		Projection:            schema.NewProjection(schema.ProjectInclude, "A", "B"),
+		ProvisionedThroughput: schema.NewThroughput(1, 1),
+	}}
+	return req
+}
+
+func describe(req *schema.CreateRequest) *schema.TableDescription {
+	desc := &schema.TableDescription{
+		TableName:            req.TableName,
+		TableStatus:          schema.StatusActive,
+		KeySchema:            req.KeySchema,
+		AttributeDefinitions: req.AttributeDefinitions,
+		StreamSpecification:  req.StreamSpecification,
+	}
+	desc.ProvisionedThroughput.ProvisionedThroughput = req.ProvisionedThroughput
+	for _, index := range req.GlobalSecondaryIndexes {
+		desc.GlobalSecondaryIndexes = append(desc.GlobalSecondaryIndexes, schema.SecondaryIndexResponse{SecondaryIndex: index, IndexStatus: schema.StatusActive})
+	}
+	for _, index := range req.LocalSecondaryIndexes {
+		desc.LocalSecondaryIndexes = append(desc.LocalSecondaryIndexes, schema.SecondaryIndexResponse{SecondaryIndex: index})
+	}
+	return desc
+}
+
+func descriptions(plan *schema.Plan) (d []string) {
+	for _, step := range plan.Steps {
+		d = append(d, step.Description)
+	}
+	return
+}
+
+func TestPlanCreate(t *testing.T) {
+	assert := assert.New(t)
+	desired := postsTable()
+	plan, err := schema.NewPlan(desired, nil)
+	assert.NoError(err)
+	assert.Equal([]string{`create table "Posts"`}, descriptions(plan))
+	assert.Equal(desired, plan.Steps[0].Create)
+	assert.False(plan.RequiresRecreate())
+}
+
+func TestPlanNoChanges(t *testing.T) {
+	assert := assert.New(t)
+	current := describe(postsTable())
+	// Projected attributes may come back in another order.
+	current.GlobalSecondaryIndexes[0].Projection.NonKeyAttributes = []string{"B", "A"}
+	plan, err := schema.NewPlan(postsTable(), current)
+	assert.NoError(err)
+	assert.True(plan.Empty())
+	assert.Equal("Plan for table \"Posts\":\n  no changes\n", plan.String())
+
+	// Local indexes get the table's hash key.
+	current = describe(postsTable().LocalSecondaryIndex("ByScore", schema.NewAttribute("Score", schema.Number), schema.NewProjection(schema.ProjectAll)))
+	desired := postsTable()
+	desired.LocalSecondaryIndexes = []schema.SecondaryIndex{{
+		IndexName:  "ByScore",
+		KeySchema:  []schema.KeySchema{{AttributeName: "Score", KeyType: schema.RangeKey}},
+		Projection: schema.NewProjection(schema.ProjectAll),
+	}}
+	plan, err = schema.NewPlan(desired, current)
+	assert.NoError(err)
+	assert.True(plan.Empty())
+}
+
+func TestPlanUpdates(t *testing.T) {
+	assert := assert.New(t)
+	current := describe(postsTable())
+	current.GlobalSecondaryIndexes = append(current.GlobalSecondaryIndexes, schema.SecondaryIndexResponse{
+		SecondaryIndex: schema.SecondaryIndex{IndexName: "Unused"},
+	})
+
+	desired := postsTable()
+	desired.ProvisionedThroughput = schema.NewThroughput(5, 6)
+	desired.GlobalSecondaryIndexes[0].ProvisionedThroughput = schema.NewThroughput(2, 2)
+	desired.AttributeDefinitions = append(desired.AttributeDefinitions, schema.AttributeDefinition{AttributeName: "Author", AttributeType: schema.String})
+	desired.GlobalSecondaryIndexes = append(desired.GlobalSecondaryIndexes, schema.SecondaryIndex{
+		IndexName:  "ByAuthor",
+		KeySchema:  []schema.KeySchema{{AttributeName: "Author", KeyType: schema.HashKey}, {AttributeName: "Dated", KeyType: schema.RangeKey}},
+		Projection: schema.NewProjection(schema.ProjectKeysOnly),
+	})
+	desired.StreamSpecification = &schema.StreamSpecification{StreamEnabled: true, StreamViewType: schema.StreamKeysOnly}
+
+	plan, err := schema.NewPlan(desired, current)
+	assert.NoError(err)
+	assert.False(plan.RequiresRecreate())
+	assert.Equal([]string{
+		`delete global secondary index "Unused"`,
+		`change table throughput to 5 read / 6 write`,
+		`change throughput of global secondary index "ByTitle" to 2 read / 2 write`,
+		`create global secondary index "ByAuthor"`,
+		`enable stream (KEYS_ONLY)`,
+	}, descriptions(plan))
+
+	create := plan.Steps[3].Update
+	assert.Equal([]schema.AttributeDefinition{
+		{AttributeName: "Author", AttributeType: schema.String},
+		{AttributeName: "Dated", AttributeType: schema.Number},
+	}, create.AttributeDefinitions)
+	assert.Equal("ByAuthor", create.GlobalSecondaryIndexUpdates[0].Create.IndexName)
+	// Without throughput of its own, the index gets the table's.
+	assert.Equal(&schema.ProvisionedThroughput{ReadCapacityUnits: 5, WriteCapacityUnits: 6}, create.GlobalSecondaryIndexUpdates[0].Create.ProvisionedThroughput)
+
+	desired.ProvisionedThroughput = schema.ProvisionedThroughput{}
+	_, err = schema.NewPlan(desired, current)
+	assert.EqualError(err, `schema: global secondary index "ByAuthor" needs provisioned throughput`)
+
+	desired.ProvisionedThroughput = schema.NewThroughput(5, 6)
+	desired.AttributeDefinitions = desired.AttributeDefinitions[:len(desired.AttributeDefinitions)-1]
+	_, err = schema.NewPlan(desired, current)
+	assert.EqualError(err, `schema: key attribute Author of global secondary index "ByAuthor" has no attribute definition`)
+}
+
+func TestPlanRedefineIndexAndStream(t *testing.T) {
+	assert := assert.New(t)
+	current := postsTable()
+	current.StreamSpecification = &schema.StreamSpecification{StreamEnabled: true, StreamViewType: schema.StreamKeysOnly}
+	desired := postsTable()
+	desired.GlobalSecondaryIndexes[0].Projection = schema.NewProjection(schema.ProjectAll)
+	desired.StreamSpecification = &schema.StreamSpecification{StreamEnabled: true, StreamViewType: schema.StreamNewImage}
+
+	plan, err := schema.NewPlan(desired, describe(current))
+	assert.NoError(err)
+	assert.Equal([]string{
+		`delete global secondary index "ByTitle" (definition changed)`,
+		`create global secondary index "ByTitle"`,
+		`disable stream (view type changed)`,
+		`enable stream (NEW_IMAGE)`,
+	}, descriptions(plan))
+
+	desired.StreamSpecification = nil
+	plan, err = schema.NewPlan(desired, describe(current))
+	assert.NoError(err)
+	assert.Equal([]string{
+		`delete global secondary index "ByTitle" (definition changed)`,
+		`create global secondary index "ByTitle"`,
+		`disable stream`,
+	}, descriptions(plan))
+}
+
+func TestPlanRecreate(t *testing.T) {
+	assert := assert.New(t)
+	current := postsTable()
+	current.LocalSecondaryIndexes = []schema.SecondaryIndex{{IndexName: "Local1"}}
+	desired := schema.NewCreateRequest("Posts").
+		HashKey("UserId", schema.String).
+		RangeKey("Title", schema.String)
+	desired.GlobalSecondaryIndexes = current.GlobalSecondaryIndexes
+	desired.LocalSecondaryIndexes = []schema.SecondaryIndex{{IndexName: "Local2"}}
+
+	plan, err := schema.NewPlan(desired, describe(current))
+	assert.NoError(err)
+	assert.True(plan.RequiresRecreate())
+	assert.Equal([]string{
+		"key schema changed from (UserId HASH, Dated RANGE) to (UserId HASH, Title RANGE)",
+		"key attribute UserId changed type from N to S",
+		`local secondary index "Local2" added`,
+		`local secondary index "Local1" removed`,
+	}, plan.Recreate)
+	assert.Contains(plan.String(), "! requires recreating table: local secondary index \"Local1\" removed\n")
+}
+
+func TestPlanBillingMode(t *testing.T) {
+	assert := assert.New(t)
+	provisioned := postsTable()
+	onDemand := postsTable().OnDemand()
+	onDemand.GlobalSecondaryIndex("ByAuthor", schema.NewAttribute("Author", schema.String), schema.AttributeDefinition{}, schema.NewProjection(schema.ProjectAll), schema.NewThroughput(9, 9))
+
+	plan, err := schema.NewPlan(onDemand, describe(provisioned))
+	assert.NoError(err)
+	assert.Equal([]string{
+		"switch billing mode to PAY_PER_REQUEST",
+		`create global secondary index "ByAuthor"`,
+	}, descriptions(plan))
+	assert.Equal(schema.BillingPayPerRequest, plan.Steps[0].Update.BillingMode)
+	assert.Nil(plan.Steps[0].Update.ProvisionedThroughput)
+	assert.Nil(plan.Steps[1].Update.GlobalSecondaryIndexUpdates[0].Create.ProvisionedThroughput)
+
+	current := describe(postsTable().OnDemand())
+	current.BillingModeSummary = &schema.BillingModeSummary{BillingMode: schema.BillingPayPerRequest}
+	plan, err = schema.NewPlan(postsTable().OnDemand(), current)
+	assert.NoError(err)
+	assert.True(plan.Empty())
+
+	plan, err = schema.NewPlan(provisioned, current)
+	assert.NoError(err)
+	assert.Equal([]string{"switch billing mode to PROVISIONED"}, descriptions(plan))
+	update := plan.Steps[0].Update
+	assert.Equal(&schema.ProvisionedThroughput{ReadCapacityUnits: 1, WriteCapacityUnits: 1}, update.ProvisionedThroughput)
+	assert.Equal("ByTitle", update.GlobalSecondaryIndexUpdates[0].Update.IndexName)
+}
@@ -1,14 +1,22 @@
 package schema
 
+import (
+	"encoding/json"
+	"sort"
+)
+
 // CreateRequest is the request to create a new DynamoDB table.
 type CreateRequest struct {
 	TableName              string
 	AttributeDefinitions   []AttributeDefinition
 	KeySchema              []KeySchema
+	BillingMode            BillingMode `json:",omitempty"`
 	ProvisionedThroughput  ProvisionedThroughput
 	GlobalSecondaryIndexes []SecondaryIndex
 	LocalSecondaryIndexes  []SecondaryIndex
 	StreamSpecification    *StreamSpecification `json:",omitempty"`
+	SSESpecification       *SSESpecification    `json:",omitempty"`
+	Tags                   []Tag                `json:",omitempty"`
 }
 
 func NewCreateRequest(table string) *CreateRequest {
@@ -22,6 +30,7 @@ func NewCreateRequest(table string) *CreateRequest {
 func (r *CreateRequest) HashKey(name string, attributeType AttributeType) *CreateRequest {
 	r.ensureAttribute(name, attributeType)
 	r.KeySchema = append(r.KeySchema, KeySchema{name, HashKey})
+	r.LocalSecondaryIndexes = r.localIndexes()
 	return r
 }
 
@@ -32,6 +41,142 @@ func (r *CreateRequest) RangeKey(name string, attributeType AttributeType) *Crea
 	return r
 }
 
+/*
+GlobalSecondaryIndex adds a global secondary index and the attribute
+definitions for its keys.
+
+rangeKey may be the zero AttributeDefinition for an index with only a hash key.
+throughput is ignored for tables using OnDemand billing.
+*/
+func (r *CreateRequest) GlobalSecondaryIndex(name string, hash, rangeKey AttributeDefinition, projection Projection, throughput ProvisionedThroughput) *CreateRequest {
+	r.GlobalSecondaryIndexes = append(r.GlobalSecondaryIndexes, SecondaryIndex{
+		IndexName:             name,
+		KeySchema:             r.indexKeys(hash, rangeKey),
+		Projection:            projection,
+		ProvisionedThroughput: throughput,
+	})
+	return r
+}
+
+/*
+LocalSecondaryIndex adds a local secondary index and the attribute definition
+for its range key.
+
+Local secondary indexes always share the table's hash key, which is added to
+the index's key schema once the table has one.
+*/
+func (r *CreateRequest) LocalSecondaryIndex(name string, rangeKey AttributeDefinition, projection Projection) *CreateRequest {
+	r.LocalSecondaryIndexes = append(r.LocalSecondaryIndexes, SecondaryIndex{
+		IndexName:  name,
+		KeySchema:  []KeySchema{{rangeKey.AttributeName, RangeKey}},
+		Projection: projection,
+	})
+	r.LocalSecondaryIndexes = r.localIndexes()
+	r.ensureAttribute(rangeKey.AttributeName, rangeKey.AttributeType)
+	return r
+}
+
+// The local secondary indexes, with the table's hash key added to any which don't have a hash key.
+func (r *CreateRequest) localIndexes() []SecondaryIndex {
+	var hash *KeySchema
+	for i := range r.KeySchema {
+		if r.KeySchema[i].KeyType == HashKey {
+			hash = &r.KeySchema[i]
+		}
+	}
+	if hash == nil {
+		return r.LocalSecondaryIndexes
+	}
+	indexes := make([]SecondaryIndex, len(r.LocalSecondaryIndexes))
+	for i, index := range r.LocalSecondaryIndexes {
+		if len(index.KeySchema) > 0 && index.KeySchema[0].KeyType != HashKey {
+			index.KeySchema = append([]KeySchema{*hash}, index.KeySchema...)
+		}
+		indexes[i] = index
+	}
+	return indexes
+}
+
+func (r *CreateRequest) indexKeys(hash, rangeKey AttributeDefinition) []KeySchema {
+	r.ensureAttribute(hash.AttributeName, hash.AttributeType)
+	keys := []KeySchema{{hash.AttributeName, HashKey}}
+	if rangeKey.AttributeName != "" {
+		r.ensureAttribute(rangeKey.AttributeName, rangeKey.AttributeType)
+		keys = append(keys, KeySchema{rangeKey.AttributeName, RangeKey})
+	}
+	return keys
+}
+
+// OnDemand switches the table to pay-per-request billing, so no throughput is provisioned.
+func (r *CreateRequest) OnDemand() *CreateRequest {
+	r.BillingMode = BillingPayPerRequest
+	r.ProvisionedThroughput = ProvisionedThroughput{}
+	return r
+}
+
+// SSE enables server-side encryption. kmsMasterKeyID is only used with SSEKMS and may be empty to use the default key.
+func (r *CreateRequest) SSE(sseType SSEType, kmsMasterKeyID string) *CreateRequest {
+	r.SSESpecification = &SSESpecification{
+		Enabled:        true,
+		SSEType:        sseType,
+		KMSMasterKeyId: kmsMasterKeyID,
+	}
+	return r
+}
+
+// Tag adds a tag to the table. Can be called multiple times to add more tags.
+func (r *CreateRequest) Tag(key, value string) *CreateRequest {
+	r.Tags = append(r.Tags, Tag{key, value})
+	return r
+}
+
+// AddTags adds several tags to the table at once, in order of their keys.
+func (r *CreateRequest) AddTags(tags map[string]string) *CreateRequest {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		r.Tag(key, tags[key])
+	}
+	return r
+}
+
+/*
+MarshalJSON encodes the request for the wire.
+
+Local secondary indexes share the table's throughput, so never send any. Tables
+using BillingPayPerRequest must not send any provisioned throughput at all, so
+it is also left off the table and the global secondary indexes in that case.
+*/
+func (r CreateRequest) MarshalJSON() ([]byte, error) {
+	type plain CreateRequest
+	type unprovisionedIndex struct {
+		IndexName  string
+		KeySchema  []KeySchema
+		Projection Projection
+	}
+	unprovisioned := func(indexes []SecondaryIndex) (output []unprovisionedIndex) {
+		for _, index := range indexes {
+			output = append(output, unprovisionedIndex{index.IndexName, index.KeySchema, index.Projection})
+		}
+		return
+	}
+	if r.BillingMode != BillingPayPerRequest {
+		return json.Marshal(struct {
+			plain
+			LocalSecondaryIndexes []unprovisionedIndex `json:",omitempty"`
+		}{plain(r), unprovisioned(r.localIndexes())})
+	}
+	return json.Marshal(struct {
+		plain
+		ProvisionedThroughput  *ProvisionedThroughput `json:",omitempty"`
+		GlobalSecondaryIndexes []unprovisionedIndex   `json:",omitempty"`
+		LocalSecondaryIndexes  []unprovisionedIndex   `json:",omitempty"`
+	}{plain(r), nil, unprovisioned(r.GlobalSecondaryIndexes), unprovisioned(r.localIndexes())})
+}
+
 func (r *CreateRequest) ensureAttribute(name string, attributeType AttributeType) {
 	for _, a := range r.AttributeDefinitions {
 		if a.AttributeName == name {
@@ -68,6 +213,146 @@ type ListResponse struct {
 	TableNames             []string
 }
 
+// UpdateRequest modifies the throughput, indexes, billing or streams of a table.
+type UpdateRequest struct {
+	TableName                   string
+	AttributeDefinitions        []AttributeDefinition        `json:",omitempty"`
+	BillingMode                 BillingMode                  `json:",omitempty"`
+	ProvisionedThroughput       *ProvisionedThroughput       `json:",omitempty"`
+	GlobalSecondaryIndexUpdates []GlobalSecondaryIndexUpdate `json:",omitempty"`
+	StreamSpecification         *StreamSpecification         `json:",omitempty"`
+}
+
+// NewUpdateRequest makes an UpdateRequest which changes nothing until built upon.
+func NewUpdateRequest(table string) *UpdateRequest {
+	return &UpdateRequest{TableName: table}
+}
+
+// Throughput changes the provisioned throughput of the table.
+func (r *UpdateRequest) Throughput(read, write uint) *UpdateRequest {
+	throughput := NewThroughput(read, write)
+	r.ProvisionedThroughput = &throughput
+	return r
+}
+
+// Billing switches the table billing mode.
+//
+// When switching to BillingProvisioned, Throughput must also be set.
+func (r *UpdateRequest) Billing(mode BillingMode) *UpdateRequest {
+	r.BillingMode = mode
+	return r
+}
+
+/*
+CreateGlobalIndex adds a new global secondary index to the table.
+
+Any attributes used in the index key schema which are not already part of the
+table must be described in attributes.
+
+If index.ProvisionedThroughput is left as the zero value it is omitted from
+the request, as is required for tables using BillingPayPerRequest.
+*/
+func (r *UpdateRequest) CreateGlobalIndex(index SecondaryIndex, attributes ...AttributeDefinition) *UpdateRequest {
+	for _, a := range attributes {
+		r.ensureAttribute(a)
+	}
+	action := &CreateGlobalSecondaryIndexAction{
+		IndexName:  index.IndexName,
+		KeySchema:  index.KeySchema,
+		Projection: index.Projection,
+	}
+	if index.ProvisionedThroughput != (ProvisionedThroughput{}) {
+		throughput := index.ProvisionedThroughput
+		action.ProvisionedThroughput = &throughput
+	}
+	r.GlobalSecondaryIndexUpdates = append(r.GlobalSecondaryIndexUpdates, GlobalSecondaryIndexUpdate{Create: action})
+	return r
+}
+
+// UpdateGlobalIndex changes the provisioned throughput of an existing global secondary index.
+func (r *UpdateRequest) UpdateGlobalIndex(name string, throughput ProvisionedThroughput) *UpdateRequest {
+	action := &UpdateGlobalSecondaryIndexAction{name, throughput}
+	r.GlobalSecondaryIndexUpdates = append(r.GlobalSecondaryIndexUpdates, GlobalSecondaryIndexUpdate{Update: action})
+	return r
+}
+
+// DeleteGlobalIndex removes a global secondary index from the table.
+func (r *UpdateRequest) DeleteGlobalIndex(name string) *UpdateRequest {
+	action := &DeleteGlobalSecondaryIndexAction{name}
+	r.GlobalSecondaryIndexUpdates = append(r.GlobalSecondaryIndexUpdates, GlobalSecondaryIndexUpdate{Delete: action})
+	return r
+}
+
+// EnableStream turns on DynamoDB streams for this table with the given view type.
+func (r *UpdateRequest) EnableStream(viewType string) *UpdateRequest {
+	r.StreamSpecification = &StreamSpecification{StreamEnabled: true, StreamViewType: viewType}
+	return r
+}
+
+// DisableStream turns off DynamoDB streams for this table.
+func (r *UpdateRequest) DisableStream() *UpdateRequest {
+	r.StreamSpecification = &StreamSpecification{StreamEnabled: false}
+	return r
+}
+
+func (r *UpdateRequest) ensureAttribute(attribute AttributeDefinition) {
+	for _, a := range r.AttributeDefinitions {
+		if a.AttributeName == attribute.AttributeName {
+			return
+		}
+	}
+	r.AttributeDefinitions = append(r.AttributeDefinitions, attribute)
+}
+
+// GlobalSecondaryIndexUpdate is a single index action; exactly one of the fields should be set.
+type GlobalSecondaryIndexUpdate struct {
+	Create *CreateGlobalSecondaryIndexAction `json:",omitempty"`
+	Update *UpdateGlobalSecondaryIndexAction `json:",omitempty"`
+	Delete *DeleteGlobalSecondaryIndexAction `json:",omitempty"`
+}
+
+type CreateGlobalSecondaryIndexAction struct {
+	IndexName             string
+	KeySchema             []KeySchema
+	Projection            Projection
+	ProvisionedThroughput *ProvisionedThroughput `json:",omitempty"`
+}
+
+type UpdateGlobalSecondaryIndexAction struct {
+	IndexName             string
+	ProvisionedThroughput ProvisionedThroughput
+}
+
+type DeleteGlobalSecondaryIndexAction struct {
+	IndexName string
+}
+
+// UpdateResult describes the table after the update was accepted.
+type UpdateResult struct {
+	TableDescription TableDescription
+}
+
+// UpdateTTLRequest enables or disables expiring items by a timestamp attribute.
+type UpdateTTLRequest struct {
+	TableName               string
+	TimeToLiveSpecification TimeToLiveSpecification
+}
+
+// UpdateTTLResult echoes the time to live settings applied.
+type UpdateTTLResult struct {
+	TimeToLiveSpecification TimeToLiveSpecification
+}
+
+// DescribeTTLRequest asks for the time to live settings on a table.
+type DescribeTTLRequest struct {
+	TableName string
+}
+
+// DescribeTTLResult gives the time to live settings on a table.
+type DescribeTTLResult struct {
+	TimeToLiveDescription TimeToLiveDescription
+}
+
 // DescribeRequest gives details about a single table.
 type DescribeRequest struct {
 	TableName string
@@ -23,6 +23,38 @@ const (
 	ProjectAll      ProjectionType = "ALL"
 )
 
+// SSEType is the kind of server-side encryption for a table.
+type SSEType string
+
+const (
+	SSEAES256 SSEType = "AES256"
+	SSEKMS    SSEType = "KMS"
+)
+
+// Values of TableStatus and IndexStatus in table descriptions.
+const (
+	StatusCreating = "CREATING"
+	StatusUpdating = "UPDATING"
+	StatusDeleting = "DELETING"
+	StatusActive   = "ACTIVE"
+)
+
+// BillingMode controls how a table is charged for reads and writes.
+type BillingMode string
+
+const (
+	BillingProvisioned   BillingMode = "PROVISIONED"
+	BillingPayPerRequest BillingMode = "PAY_PER_REQUEST"
+)
+
+// Valid values for StreamSpecification.StreamViewType
+const (
+	StreamKeysOnly        = "KEYS_ONLY"
+	StreamNewImage        = "NEW_IMAGE"
+	StreamOldImage        = "OLD_IMAGE"
+	StreamNewAndOldImages = "NEW_AND_OLD_IMAGES"
+)
+
 type TableDescription struct {
 	TableName        string
 	TableSizeBytes   uint64
@@ -34,6 +66,8 @@ type TableDescription struct {
 	GlobalSecondaryIndexes []SecondaryIndexResponse
 	LocalSecondaryIndexes  []SecondaryIndexResponse
 	ProvisionedThroughput  ProvisionedThroughputDescription
+	BillingModeSummary     *BillingModeSummary
+	SSEDescription         *SSEDescription
 
 	// Streams
 	LatestStreamArn     string
@@ -56,6 +90,11 @@ type AttributeDefinition struct {
 	AttributeType AttributeType
 }
 
+// NewAttribute is a shortcut to create an AttributeDefinition without using unkeyed literals.
+func NewAttribute(name string, attributeType AttributeType) AttributeDefinition {
+	return AttributeDefinition{name, attributeType}
+}
+
 type KeySchema struct {
 	AttributeName string
 	KeyType       KeyType
@@ -97,7 +136,42 @@ type ProvisionedThroughputDescription struct {
 	NumberOfDecreasesToday int
 }
 
+// BillingModeSummary is only present on tables which have had a billing mode set.
+type BillingModeSummary struct {
+	BillingMode                       BillingMode
+	LastUpdateToPayPerRequestDateTime float64
+}
+
+type SSESpecification struct {
+	Enabled        bool
+	SSEType        SSEType `json:",omitempty"`
+	KMSMasterKeyId string  `json:",omitempty"`
+}
+
+// SSEDescription is the server-side encryption status of a table.
+type SSEDescription struct {
+	Status          string
+	SSEType         SSEType
+	KMSMasterKeyArn string
+}
+
+type Tag struct {
+	Key   string
+	Value string
+}
+
+type TimeToLiveSpecification struct {
+	AttributeName string
+	Enabled       bool
+}
+
+// TimeToLiveDescription describes the TTL settings on a table. TimeToLiveStatus is one of ENABLING, DISABLING, ENABLED or DISABLED.
+type TimeToLiveDescription struct {
+	AttributeName    string
+	TimeToLiveStatus string
+}
+
 type StreamSpecification struct {
 	StreamEnabled  bool
-	StreamViewType string
+	StreamViewType string `json:",omitempty"`
 }
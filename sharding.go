@@ -0,0 +1,324 @@
+package dynago
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"math/rand"
+	"sync"
+)
+
+// ShardKey gives the hash key value for one shard of a sharded key, e.g. "views#3".
+func ShardKey(value interface{}, shard int) string {
+	return fmt.Sprintf("%v#%d", value, shard)
+}
+
+/*
+Sharding spreads the items of a hot hash key over several hash key values, from
+"key#0" to "key#N-1", so writes to them are spread over several partitions.
+
+Writes pick a shard with Key:
+
+	sharding := dynago.Sharding{Shards: 8, Attribute: "OrderId"}
+	order["Customer"] = sharding.Key(customerId, order)
+	client.PutItem("Orders", order).Execute()
+
+and reads query every shard with Query.
+*/
+type Sharding struct {
+	Shards int // Number of shards, at least 1
+
+	// Attribute, if set, picks the shard from a hash of this attribute of the
+	// item, so the same item always lands in the same shard. Otherwise, the
+	// shard is picked at random.
+	Attribute string
+}
+
+// Shard picks the shard for an item.
+func (s Sharding) Shard(item Document) int {
+	shards := s.shards()
+	if s.Attribute == "" {
+		return rand.Intn(shards)
+	}
+	h := fnv.New32a()
+	switch v := item[s.Attribute].(type) {
+	case string:
+		h.Write([]byte(v))
+	case []byte:
+		h.Write(v)
+	default:
+		if n := numberString(v); n != "" {
+			h.Write([]byte(n))
+		} else {
+			fmt.Fprint(h, v)
+		}
+	}
+	return int(h.Sum32() % uint32(shards))
+}
+
+// Key gives the sharded hash key value for writing item.
+func (s Sharding) Key(value interface{}, item Document) string {
+	return ShardKey(value, s.Shard(item))
+}
+
+// AllKeys gives the hash key values of all the shards.
+func (s Sharding) AllKeys(value interface{}) []string {
+	keys := make([]string, s.shards())
+	for i := range keys {
+		keys[i] = ShardKey(value, i)
+	}
+	return keys
+}
+
+func (s Sharding) shards() int {
+	if s.Shards < 1 {
+		return 1
+	}
+	return s.Shards
+}
+
+/*
+Query makes a query which runs query against every shard of the hash key value,
+and merges the results in order of the range key.
+
+The hash key in query's key condition must be the parameter param, which is set
+to each shard's key. rangeKey is the range key of the table or index queried,
+which is used to merge the results:
+
+	query := client.Query("Orders").
+		KeyConditionExpression("Customer = :customer AND Created > :since", dynago.P(":since", since))
+	result, err := sharding.Query(query, ":customer", customerId, "Created").Execute()
+*/
+func (s Sharding) Query(query *Query, param string, value interface{}, rangeKey string) *ShardedQuery {
+	return &ShardedQuery{query: query, sharding: s, param: param, value: value, rangeKey: rangeKey}
+}
+
+/*
+ShardedQuery runs a Query against every shard of a sharded key in parallel,
+merging the items by range key in the order set with ScanIndexForward.
+
+Each page of results has the items from all shards which are known to come
+before any items not yet fetched, so merged pages are in order. A Limit on the
+query applies to each shard, so a page has up to Limit items per shard. Items
+must include their key attributes, for building the cursor.
+
+Like Query, a ShardedQuery is immutable; methods give a changed copy.
+*/
+type ShardedQuery struct {
+	query    *Query
+	sharding Sharding
+	param    string
+	value    interface{}
+	rangeKey string
+	cursor   *ShardedCursor
+}
+
+/*
+Cursor sets where each shard starts, to continue from a previous page, such as
+one returned to a client as a string:
+
+	cursor, err := dynago.DecodeShardedCursor(token)
+	result, err := sharding.Query(query, ":customer", customerId, "Created").Cursor(cursor).Execute()
+*/
+func (q ShardedQuery) Cursor(cursor *ShardedCursor) *ShardedQuery {
+	q.cursor = cursor
+	return &q
+}
+
+// Execute runs the query on all unfinished shards, and merges the results.
+func (q *ShardedQuery) Execute() (*ShardedQueryResult, error) {
+	shards := q.sharding.shards()
+	results := make([]*QueryResult, shards)
+	errs := make([]error, shards)
+	var wg sync.WaitGroup
+	for i := 0; i < shards; i++ {
+		start, done := q.cursor.shard(i)
+		if done {
+			continue
+		}
+		query := q.query.Param(q.param, ShardKey(q.value, i))
+		if len(start) > 0 {
+			query = query.ExclusiveStartKey(start)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = query.Execute()
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return q.merge(results), nil
+}
+
+func (q *ShardedQuery) merge(results []*QueryResult) *ShardedQueryResult {
+	forward := q.query.req.ScanIndexForward == nil || *q.query.req.ScanIndexForward
+	before := func(a, b interface{}) bool {
+		c := compareKeyValues(a, b)
+		return (forward && c < 0) || (!forward && c > 0)
+	}
+
+	// Items after the lowest range key evaluated by an unfinished shard can't
+	// be returned yet, since that shard could have items before them.
+	var watermark interface{}
+	var keyNames Document
+	for _, result := range results {
+		if result != nil && len(result.LastEvaluatedKey) > 0 {
+			keyNames = result.LastEvaluatedKey
+			if last := result.LastEvaluatedKey[q.rangeKey]; watermark == nil || before(last, watermark) {
+				watermark = last
+			}
+		}
+	}
+
+	merged := &ShardedQueryResult{query: q}
+	used := make([]int, len(results))
+	for {
+		next := -1
+		for i, result := range results {
+			if result == nil || used[i] >= len(result.Items) {
+				continue
+			}
+			value := result.Items[used[i]][q.rangeKey]
+			if watermark != nil && before(watermark, value) {
+				continue
+			}
+			if next < 0 || before(value, results[next].Items[used[next]][q.rangeKey]) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		merged.Items = append(merged.Items, results[next].Items[used[next]])
+		used[next]++
+	}
+
+	cursor := &ShardedCursor{Keys: make([]Document, len(results)), Done: make([]bool, len(results))}
+	finished := true
+	for i, result := range results {
+		start, done := q.cursor.shard(i)
+		switch {
+		case result == nil:
+			cursor.Keys[i], cursor.Done[i] = start, done
+		case used[i] < len(result.Items):
+			if used[i] > 0 {
+				start = keyOf(result.Items[used[i]-1], keyNames)
+			}
+			cursor.Keys[i] = start
+		case len(result.LastEvaluatedKey) > 0:
+			cursor.Keys[i] = result.LastEvaluatedKey
+		default:
+			cursor.Done[i] = true
+		}
+		finished = finished && cursor.Done[i]
+	}
+	if !finished {
+		merged.Cursor = cursor
+	}
+	return merged
+}
+
+// ShardedQueryResult is a page of merged results from a ShardedQuery.
+type ShardedQueryResult struct {
+	query  *ShardedQuery
+	Items  []Document     // Items from all shards, in range key order
+	Cursor *ShardedCursor // Where to continue from, or nil after the last page
+}
+
+// Next returns a query which gets the next page of results, or nil if there's no next page.
+func (r *ShardedQueryResult) Next() *ShardedQuery {
+	if r.Cursor == nil {
+		return nil
+	}
+	return r.query.Cursor(r.Cursor)
+}
+
+/*
+ShardedCursor is where each shard of a ShardedQuery is up to. It can be passed
+around as a string with Encode and DecodeShardedCursor.
+*/
+type ShardedCursor struct {
+	Keys []Document // The exclusive start key of each shard, or nil to start at the beginning
+	Done []bool     // Whether each shard has no more items
+}
+
+// Encode makes the cursor into a URL-safe string.
+func (c *ShardedCursor) Encode() (string, error) {
+	buf, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// DecodeShardedCursor reads a cursor made by ShardedCursor.Encode.
+func DecodeShardedCursor(s string) (*ShardedCursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("dynago: invalid sharded cursor: %w", err)
+	}
+	var c ShardedCursor
+	if err := json.Unmarshal(buf, &c); err != nil {
+		return nil, fmt.Errorf("dynago: invalid sharded cursor: %w", err)
+	}
+	return &c, nil
+}
+
+// The start key for a shard, and whether it's done. A nil cursor starts all shards from the beginning.
+func (c *ShardedCursor) shard(i int) (Document, bool) {
+	if c == nil {
+		return nil, false
+	}
+	var start Document
+	if i < len(c.Keys) {
+		start = c.Keys[i]
+	}
+	return start, i < len(c.Done) && c.Done[i]
+}
+
+// The key of item, with the attributes named in names.
+func keyOf(item Document, names Document) Document {
+	key := make(Document, len(names))
+	for name := range names {
+		key[name] = item[name]
+	}
+	return key
+}
+
+/*
+Compare key values the way DynamoDB orders them: numbers by value, strings and
+binary values by their bytes.
+*/
+func compareKeyValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			}
+			return 0
+		}
+	case []byte:
+		if b, ok := b.([]byte); ok {
+			return bytes.Compare(a, b)
+		}
+	}
+	// DynamoDB numbers have up to 38 digits, which doesn't fit a float64.
+	x, okA := new(big.Float).SetPrec(128).SetString(numberString(a))
+	y, okB := new(big.Float).SetPrec(128).SetString(numberString(b))
+	if okA && okB {
+		return x.Cmp(y)
+	}
+	return compareKeyValues(fmt.Sprint(a), fmt.Sprint(b))
+}
@@ -0,0 +1,125 @@
+package dynago
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func shardPage(shard string, start bool) func(*MockExecutorCall) bool {
+	return func(call *MockExecutorCall) bool {
+		return call.ExpressionAttributeValues[":c"] == shard && (call.ExclusiveStartKey != nil) == start
+	}
+}
+
+func order(shard string, created int) Document {
+	return Document{"Customer": shard, "Created": Number(strconv.Itoa(created))}
+}
+
+func TestSharding(t *testing.T) {
+	assert := assert.New(t)
+	sharding := Sharding{Shards: 4, Attribute: "OrderId"}
+	shard := sharding.Shard(Document{"OrderId": 42})
+	assert.Equal(shard, sharding.Shard(Document{"OrderId": Number("42")}))
+	assert.True(shard >= 0 && shard < 4)
+	assert.Equal(ShardKey("bob", shard), sharding.Key("bob", Document{"OrderId": 42}))
+	assert.Equal([]string{"bob#0", "bob#1", "bob#2", "bob#3"}, sharding.AllKeys("bob"))
+
+	random := Sharding{Shards: 3}
+	for i := 0; i < 20; i++ {
+		shard := random.Shard(nil)
+		assert.True(shard >= 0 && shard < 3)
+	}
+	assert.Equal(0, Sharding{}.Shard(nil))
+}
+
+func TestShardedQuery(t *testing.T) {
+	assert, client, executor := setUp(t)
+	executor.Expect("Query").Match(shardPage("c#0", false)).Return(&QueryResult{
+		Items:            []Document{order("c#0", 1), order("c#0", 4)},
+		LastEvaluatedKey: order("c#0", 4),
+	})
+	executor.Expect("Query").Match(shardPage("c#1", false)).Return(&QueryResult{
+		Items: []Document{order("c#1", 2), order("c#1", 3), order("c#1", 6)},
+	})
+	executor.Expect("Query").Match(shardPage("c#0", true)).Return(&QueryResult{
+		Items: []Document{order("c#0", 5)},
+	})
+	executor.Expect("Query").Match(shardPage("c#1", true)).Return(&QueryResult{
+		Items: []Document{order("c#1", 6)},
+	})
+
+	query := client.Query("Orders").KeyConditionExpression("Customer = :c")
+	sharded := Sharding{Shards: 2}.Query(query, ":c", "c", "Created")
+	result, err := sharded.Execute()
+	assert.NoError(err)
+	// Shard 1 has order 6 but shard 0 could still have orders before it.
+	assert.Equal([]Document{order("c#0", 1), order("c#1", 2), order("c#1", 3), order("c#0", 4)}, result.Items)
+	assert.Equal(&ShardedCursor{
+		Keys: []Document{order("c#0", 4), order("c#1", 3)},
+		Done: []bool{false, false},
+	}, result.Cursor)
+
+	// The cursor can be passed around as a string.
+	token, err := result.Cursor.Encode()
+	assert.NoError(err)
+	cursor, err := DecodeShardedCursor(token)
+	assert.NoError(err)
+	result, err = sharded.Cursor(cursor).Execute()
+	assert.NoError(err)
+	assert.Equal([]Document{order("c#0", 5), order("c#1", 6)}, result.Items)
+	assert.Nil(result.Cursor)
+	assert.Nil(result.Next())
+	assert.NoError(executor.ExpectationsMet())
+
+	_, err = DecodeShardedCursor("!")
+	assert.Error(err)
+}
+
+func TestShardedQueryDescending(t *testing.T) {
+	assert, client, executor := setUp(t)
+	executor.Expect("Query").Match(shardPage("c#0", false)).Return(&QueryResult{
+		Items:            []Document{order("c#0", 9), order("c#0", 5)},
+		LastEvaluatedKey: order("c#0", 5),
+	})
+	executor.Expect("Query").Match(shardPage("c#1", false)).Return(&QueryResult{
+		Items: []Document{order("c#1", 7), order("c#1", 2)},
+	})
+	executor.Expect("Query").Match(shardPage("c#0", true)).Return(&QueryResult{
+		Items: []Document{order("c#0", 3)},
+	})
+	executor.Expect("Query").Match(shardPage("c#1", true)).Return(&QueryResult{
+		Items: []Document{order("c#1", 2)},
+	})
+
+	query := client.Query("Orders").KeyConditionExpression("Customer = :c").Desc()
+	result, err := Sharding{Shards: 2}.Query(query, ":c", "c", "Created").Execute()
+	assert.NoError(err)
+	assert.Equal([]Document{order("c#0", 9), order("c#1", 7), order("c#0", 5)}, result.Items)
+
+	result, err = result.Next().Execute()
+	assert.NoError(err)
+	assert.Equal([]Document{order("c#0", 3), order("c#1", 2)}, result.Items)
+	assert.Nil(result.Cursor)
+	assert.NoError(executor.ExpectationsMet())
+}
+
+func TestCompareKeyValues(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(-1, compareKeyValues(Number("9"), Number("10")))
+	assert.Equal(0, compareKeyValues(Number("10"), 10))
+	assert.Equal(1, compareKeyValues(Number("12345678901234567890123456789012345679"), Number("12345678901234567890123456789012345678")))
+	assert.Equal(-1, compareKeyValues("10", "9"))
+	assert.Equal(1, compareKeyValues([]byte{2}, []byte{1, 5}))
+}
+
+func TestShardedQuerySharedResult(t *testing.T) {
+	assert, client, executor := setUp(t)
+	executor.QueryResult = &QueryResult{Items: []Document{order("c", 1)}}
+	query := client.Query("Orders").KeyConditionExpression("Customer = :c")
+	result, err := Sharding{Shards: 4}.Query(query, ":c", "c", "Created").Execute()
+	assert.NoError(err)
+	assert.Equal(4, len(result.Items))
+	assert.Nil(executor.QueryResult.query)
+}
@@ -0,0 +1,274 @@
+package dynago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
+// ErrItemNotFound is returned by Table.Get when there is no item with the key.
+var ErrItemNotFound = errors.New("dynago: item not found")
+
+// Most keys DynamoDB accepts in a single BatchGetItem call.
+const maxBatchGetKeys = 100
+
+/*
+Key is the primary key of an item in a Table: the value of the hash key, and
+the value of the range key if the table has one.
+
+Keys are used as map keys by Table.BatchGet, so the values must be comparable;
+BatchGet returns an error for binary keys.
+*/
+type Key struct {
+	Hash  interface{}
+	Range interface{}
+}
+
+/*
+Table gives typed access to a table, converting between items and values of
+type T with MarshalItem and UnmarshalItem. T is normally a struct type.
+
+	type User struct {
+		Id   int `dynago:"UserId"`
+		Name string
+	}
+
+	users := dynago.NewTable[User](client, "Users", "UserId", "")
+	err := users.Put(ctx, User{Id: 42, Name: "Bob"})
+	user, err := users.Get(ctx, dynago.Key{Hash: 42})
+
+If T has a field tagged `dynago:",version"`, it is used as the version
+attribute for optimistic locking, as with Client.SetVersionAttribute: Put fails
+with ErrVersionConflict unless the stored item has the version of the item
+being put, and Put and Update increment the version.
+
+The builder-based API on Client is still available for anything Table doesn't
+cover. Executors don't take a context, so ctx is checked before each request
+rather than canceling requests in flight.
+*/
+type Table[T any] struct {
+	client  *Client
+	name    string
+	keys    keyMeta
+	version string // Version attribute from the struct tags of T
+}
+
+/*
+NewTable makes a Table for the table name, whose primary key is made of the
+attributes hashKey and rangeKey. rangeKey is empty if the table has no range key.
+*/
+func NewTable[T any](client *Client, name, hashKey, rangeKey string) *Table[T] {
+	return &Table[T]{
+		client: client,
+		name:   name,
+		keys: keyMeta{
+			hash:     schema.AttributeDefinition{AttributeName: hashKey},
+			rangeKey: schema.AttributeDefinition{AttributeName: rangeKey},
+		},
+		version: versionField(reflect.TypeOf((*T)(nil)).Elem()),
+	}
+}
+
+// Name gives the name of the table.
+func (t *Table[T]) Name() string {
+	return t.name
+}
+
+// Key gives the key of an item as a Document.
+func (t *Table[T]) Key(key Key) Document {
+	if t.keys.rangeKey.AttributeName == "" {
+		return HashKey(t.keys.hash.AttributeName, key.Hash)
+	}
+	return HashRangeKey(t.keys.hash.AttributeName, key.Hash, t.keys.rangeKey.AttributeName, key.Range)
+}
+
+// Get gets the item with the given key, or ErrItemNotFound if there is none.
+func (t *Table[T]) Get(ctx context.Context, key Key) (item T, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	result, err := t.client.GetItem(t.name, t.Key(key)).Execute()
+	if err != nil {
+		return
+	} else if result == nil || result.Item == nil {
+		return item, ErrItemNotFound
+	}
+	return t.decode(result.Item)
+}
+
+// Put creates or replaces an item.
+func (t *Table[T]) Put(ctx context.Context, item T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	doc, err := MarshalItem(item)
+	if err != nil {
+		return err
+	}
+	_, err = t.client.PutItem(t.name, doc).VersionAttribute(t.version).Execute()
+	return err
+}
+
+// Delete deletes the item with the given key, if there is one.
+func (t *Table[T]) Delete(ctx context.Context, key Key) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := t.client.DeleteItem(t.name, t.Key(key)).Execute()
+	return err
+}
+
+/*
+Update applies an update expression to the item with the given key, and gives
+the item as it is after the update:
+
+	user, err := users.Update(ctx, dynago.Key{Hash: 42}, "SET #n = :name",
+		dynago.P("#n", "Name"), dynago.P(":name", "Robert"))
+*/
+func (t *Table[T]) Update(ctx context.Context, key Key, expression string, params ...Params) (item T, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	result, err := t.client.UpdateItem(t.name, t.Key(key)).
+		UpdateExpression(expression, params...).
+		VersionAttribute(t.version).
+		ReturnValues(ReturnAllNew).
+		Execute()
+	if err != nil || result == nil {
+		return
+	}
+	return t.decode(result.Attributes)
+}
+
+/*
+Query starts a query on this table, to be run with QueryAll or QueryItems:
+
+	query := posts.Query().HashKey("UserId", 42)
+	userPosts, err := posts.QueryAll(ctx, query)
+*/
+func (t *Table[T]) Query() *Query {
+	return t.client.Query(t.name)
+}
+
+// QueryAll runs a query and gives the items from all pages of results.
+func (t *Table[T]) QueryAll(ctx context.Context, query *Query) (items []T, err error) {
+	t.QueryItems(ctx, query)(func(item T, itemErr error) bool {
+		if err = itemErr; err != nil {
+			return false
+		}
+		items = append(items, item)
+		return true
+	})
+	return
+}
+
+/*
+QueryItems runs a query and iterates over the items in all pages of results,
+only fetching each page when it's needed:
+
+	for post, err := range posts.QueryItems(ctx, query) {
+		if err != nil {
+			return err
+		}
+		...
+	}
+*/
+func (t *Table[T]) QueryItems(ctx context.Context, query *Query) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		var zero T
+		for query != nil {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			result, err := query.Execute()
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, doc := range result.Items {
+				item, err := t.decode(doc)
+				if !yield(item, err) || err != nil {
+					return
+				}
+			}
+			query = result.Next()
+		}
+	}
+}
+
+/*
+BatchGet gets many items by key, in batches of up to 100 keys, retrying any
+unprocessed keys. Keys with no item are left out of the result, as are items
+which don't match a requested key, such as items missing their key attributes.
+*/
+func (t *Table[T]) BatchGet(ctx context.Context, keys []Key) (map[Key]T, error) {
+	for _, key := range keys {
+		for _, value := range []interface{}{key.Hash, key.Range} {
+			if value != nil && !reflect.TypeOf(value).Comparable() {
+				return nil, fmt.Errorf("dynago: BatchGet can't use a key of type %T as a map key", value)
+			}
+		}
+	}
+	requested := make(map[string]Key, len(keys))
+	var docs []Document
+	for _, key := range keys {
+		doc := t.Key(key)
+		canonical := t.keys.canonical(doc)
+		if _, ok := requested[canonical]; !ok {
+			requested[canonical] = key
+			docs = append(docs, doc)
+		}
+	}
+
+	items := make(map[Key]T, len(keys))
+	delay := 50 * time.Millisecond
+	for len(docs) > 0 {
+		if err := ctx.Err(); err != nil {
+			return items, err
+		}
+		batch := docs
+		if len(batch) > maxBatchGetKeys {
+			batch = batch[:maxBatchGetKeys]
+		}
+		docs = docs[len(batch):]
+		result, err := t.client.BatchGet().Get(t.name, batch...).Execute()
+		if err != nil {
+			return items, err
+		} else if result == nil {
+			continue
+		}
+		for _, doc := range result.Responses[t.name] {
+			key, ok := requested[t.keys.canonical(doc)]
+			if !ok {
+				continue // Can't tell which key it is for
+			}
+			item, err := t.decode(doc)
+			if err != nil {
+				return items, err
+			}
+			items[key] = item
+		}
+		if unprocessed := result.UnprocessedKeys[t.name]; unprocessed != nil && len(unprocessed.Keys) > 0 {
+			docs = append(docs, unprocessed.Keys...)
+			select {
+			case <-ctx.Done():
+				return items, ctx.Err()
+			case <-time.After(delay):
+			}
+			if delay *= 2; delay > time.Second {
+				delay = time.Second
+			}
+		}
+	}
+	return items, nil
+}
+
+func (t *Table[T]) decode(doc Document) (item T, err error) {
+	err = UnmarshalItem(doc, &item)
+	return
+}
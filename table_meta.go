@@ -0,0 +1,287 @@
+package dynago
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
+/*
+KeyError is returned when a key doesn't match the key schema of a table.
+
+Keys are only checked for tables whose schema the client knows about, either
+from RegisterTable or LoadTable.
+*/
+type KeyError struct {
+	Table   string
+	Index   string // Set if the key was for a secondary index
+	Message string // e.g. "missing range key Date"
+}
+
+// Error formats this error as a string.
+func (e *KeyError) Error() string {
+	if e.Index != "" {
+		return fmt.Sprintf("dynago: invalid key for table %q index %q: %s", e.Table, e.Index, e.Message)
+	}
+	return fmt.Sprintf("dynago: invalid key for table %q: %s", e.Table, e.Message)
+}
+
+/*
+RegisterTable tells the client the key schema of a table from its definition.
+
+Once a table is registered, GetItem, DeleteItem, UpdateItem and BatchGet check
+their keys before sending the request, and KeyOf works without needing to call
+DescribeTable.
+*/
+func (c *Client) RegisterTable(req *schema.CreateRequest) {
+	c.tables.set(req.TableName, newTableMeta(req.KeySchema, req.AttributeDefinitions, req.GlobalSecondaryIndexes, req.LocalSecondaryIndexes))
+}
+
+// LoadTable registers the key schema of a table using DescribeTable.
+func (c *Client) LoadTable(table string) error {
+	_, err := c.loadTable(table)
+	return err
+}
+
+func (c *Client) loadTable(table string) (*tableMeta, error) {
+	resp, err := c.DescribeTable(table)
+	if err != nil {
+		return nil, err
+	}
+	desc := &resp.Table
+	meta := newTableMeta(desc.KeySchema, desc.AttributeDefinitions, plainIndexes(desc.GlobalSecondaryIndexes), plainIndexes(desc.LocalSecondaryIndexes))
+	c.tables.set(table, meta)
+	return meta, nil
+}
+
+// Get the metadata for table, loading it if it's not known yet.
+func (c *Client) tableMeta(table string) (*tableMeta, error) {
+	if meta := c.tables.get(table); meta != nil {
+		return meta, nil
+	}
+	return c.loadTable(table)
+}
+
+/*
+KeyOf extracts the primary key of table from a full item.
+
+If the table is not registered, its schema is loaded with DescribeTable first.
+*/
+func (c *Client) KeyOf(table string, item Document) (Document, error) {
+	meta, err := c.tableMeta(table)
+	if err != nil {
+		return nil, err
+	}
+	key := Document{}
+	if err := meta.primary.extract(item, key); err != nil {
+		return nil, &KeyError{Table: table, Message: err.Error()}
+	}
+	return key, nil
+}
+
+/*
+KeyOfIndex extracts the key of an item in a secondary index.
+
+The result contains both the index key and the table's primary key, which
+together identify the item within the index, as in a LastEvaluatedKey.
+*/
+func (c *Client) KeyOfIndex(table, index string, item Document) (Document, error) {
+	meta, err := c.tableMeta(table)
+	if err != nil {
+		return nil, err
+	}
+	indexKey, ok := meta.indexes[index]
+	if !ok {
+		return nil, &KeyError{Table: table, Index: index, Message: "no such index"}
+	}
+	key := Document{}
+	for _, k := range []keyMeta{indexKey, meta.primary} {
+		if err := k.extract(item, key); err != nil {
+			return nil, &KeyError{Table: table, Index: index, Message: err.Error()}
+		}
+	}
+	return key, nil
+}
+
+/*
+ValidateKey checks that key has exactly the primary key attributes of table,
+with the right types.
+
+Tables which are not registered are not checked, so this returns nil.
+*/
+func (c *Client) ValidateKey(table string, key Document) error {
+	if meta := c.tables.get(table); meta != nil {
+		if err := meta.primary.validate(key); err != nil {
+			return &KeyError{Table: table, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+type tableCache struct {
+	lock   sync.RWMutex
+	tables map[string]*tableMeta
+}
+
+func (c *tableCache) get(table string) *tableMeta {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.tables[table]
+}
+
+func (c *tableCache) set(table string, meta *tableMeta) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.tables == nil {
+		c.tables = make(map[string]*tableMeta)
+	}
+	c.tables[table] = meta
+}
+
+type tableMeta struct {
+	primary keyMeta
+	indexes map[string]keyMeta
+}
+
+// keyMeta describes a hash key and optional range key.
+type keyMeta struct {
+	hash     schema.AttributeDefinition
+	rangeKey schema.AttributeDefinition // AttributeName is empty if there is no range key
+}
+
+func newTableMeta(keys []schema.KeySchema, attributes []schema.AttributeDefinition, indexLists ...[]schema.SecondaryIndex) *tableMeta {
+	types := make(map[string]schema.AttributeType, len(attributes))
+	for _, a := range attributes {
+		types[a.AttributeName] = a.AttributeType
+	}
+	meta := &tableMeta{primary: newKeyMeta(keys, types), indexes: make(map[string]keyMeta)}
+	for _, indexes := range indexLists {
+		for _, index := range indexes {
+			meta.indexes[index.IndexName] = newKeyMeta(index.KeySchema, types)
+		}
+	}
+	return meta
+}
+
+func newKeyMeta(keys []schema.KeySchema, types map[string]schema.AttributeType) (k keyMeta) {
+	for _, key := range keys {
+		def := schema.AttributeDefinition{AttributeName: key.AttributeName, AttributeType: types[key.AttributeName]}
+		if key.KeyType == schema.HashKey {
+			k.hash = def
+		} else {
+			k.rangeKey = def
+		}
+	}
+	return
+}
+
+func (k keyMeta) attributes() []schema.AttributeDefinition {
+	if k.rangeKey.AttributeName == "" {
+		return []schema.AttributeDefinition{k.hash}
+	}
+	return []schema.AttributeDefinition{k.hash, k.rangeKey}
+}
+
+// Copy the key attributes of item into dest, checking their types.
+func (k keyMeta) extract(item Document, dest Document) error {
+	for i, attr := range k.attributes() {
+		kind := "hash"
+		if i == 1 {
+			kind = "range"
+		}
+		val, ok := item[attr.AttributeName]
+		if !ok || val == nil {
+			return fmt.Errorf("missing %s key %s", kind, attr.AttributeName)
+		}
+		if keyAttributeType(val) != attr.AttributeType {
+			return fmt.Errorf("%s key %s should be of type %s, not %T", kind, attr.AttributeName, attr.AttributeType, val)
+		}
+		if t, ok := keyTime(val); ok && t.Location() != time.UTC {
+			return fmt.Errorf("%s key %s should be a UTC time, not %s", kind, attr.AttributeName, t.Location())
+		}
+		dest[attr.AttributeName] = val
+	}
+	return nil
+}
+
+func (k keyMeta) validate(key Document) error {
+	if err := k.extract(key, Document{}); err != nil {
+		return err
+	}
+	for name := range key {
+		if name != k.hash.AttributeName && name != k.rangeKey.AttributeName {
+			return fmt.Errorf("unexpected attribute %s in key", name)
+		}
+	}
+	return nil
+}
+
+// canonical gives a string which is the same for equal keys, even if the
+// values were given as different Go types, such as 5 and Number("5").
+func (k keyMeta) canonical(key Document) string {
+	s := ""
+	for _, attr := range k.attributes() {
+		val := key[attr.AttributeName]
+		switch keyAttributeType(val) {
+		case schema.String:
+			if t, ok := keyTime(val); ok {
+				val = t.UTC().Format(iso8601compact)
+			}
+			s += fmt.Sprintf("%q;", val)
+		case schema.Binary:
+			s += fmt.Sprintf("%x;", val)
+		case schema.Number:
+			n := wireEncode(val).(*wireNumber).N
+			if r, ok := new(big.Rat).SetString(n); ok {
+				s += r.RatString() + ";"
+			} else {
+				s += n + ";"
+			}
+		default:
+			s += fmt.Sprintf("%v;", val)
+		}
+	}
+	return s
+}
+
+// keyAttributeType gives the type a key value is sent as by wireEncode, or ""
+// for values which can't be keys.
+func keyAttributeType(value interface{}) schema.AttributeType {
+	switch v := value.(type) {
+	case string, time.Time:
+		return schema.String
+	case *time.Time:
+		if v != nil {
+			return schema.String
+		}
+	case []byte:
+		return schema.Binary
+	case int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8, float64, float32, Number:
+		return schema.Number
+	}
+	return ""
+}
+
+// keyTime gives the time of a time.Time or *time.Time key value.
+func keyTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func plainIndexes(indexes []schema.SecondaryIndexResponse) []schema.SecondaryIndex {
+	output := make([]schema.SecondaryIndex, len(indexes))
+	for i, index := range indexes {
+		output[i] = index.SecondaryIndex
+	}
+	return output
+}
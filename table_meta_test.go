@@ -0,0 +1,116 @@
+package dynago
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
+func postsSchema() *schema.CreateRequest {
+	return schema.NewCreateRequest("Posts").
+		HashKey("UserId", schema.Number).
+		RangeKey("Date", schema.String).
+		GlobalSecondaryIndex("ByTitle", schema.NewAttribute("Title", schema.String), schema.AttributeDefinition{},
+			schema.NewProjection(schema.ProjectAll), schema.NewThroughput(1, 1))
+}
+
+func TestKeyOf(t *testing.T) {
+	assert, client, _ := setUp(t)
+	client.RegisterTable(postsSchema())
+	item := Document{"UserId": 42, "Date": "2015-01-01", "Title": "Hello", "Body": "..."}
+
+	key, err := client.KeyOf("Posts", item)
+	assert.NoError(err)
+	assert.Equal(HashRangeKey("UserId", 42, "Date", "2015-01-01"), key)
+
+	key, err = client.KeyOfIndex("Posts", "ByTitle", item)
+	assert.NoError(err)
+	assert.Equal(Document{"UserId": 42, "Date": "2015-01-01", "Title": "Hello"}, key)
+
+	_, err = client.KeyOf("Posts", Document{"UserId": 42})
+	assert.Equal(`dynago: invalid key for table "Posts": missing range key Date`, err.Error())
+
+	_, err = client.KeyOfIndex("Posts", "ByTitle", Document{"UserId": 42, "Date": "x", "Title": 5})
+	assert.Equal(`dynago: invalid key for table "Posts" index "ByTitle": hash key Title should be of type S, not int`, err.Error())
+
+	_, err = client.KeyOfIndex("Posts", "Bogus", item)
+	assert.IsType(&KeyError{}, err)
+}
+
+func TestKeyOfLoadsTable(t *testing.T) {
+	assert, _, _ := setUp(t)
+	desc := &schema.TableDescription{
+		TableName:            "Person",
+		KeySchema:            []schema.KeySchema{{AttributeName: "Id", KeyType: schema.HashKey}},
+		AttributeDefinitions: []schema.AttributeDefinition{schema.NewAttribute("Id", schema.Binary)},
+	}
+	seq := &describeSequence{tables: []*schema.TableDescription{desc}}
+	client := &Client{executor: &MockExecutor{}, schemaExecutor: seq}
+	key, err := client.KeyOf("Person", Document{"Id": []byte("abc"), "Name": "Bob"})
+	assert.NoError(err)
+	assert.Equal(HashKey("Id", []byte("abc")), key)
+	_, err = client.KeyOf("Person", Document{"Id": []byte("def")})
+	assert.NoError(err)
+	assert.Equal(1, seq.calls)
+}
+
+func TestValidateKeyBeforeExecute(t *testing.T) {
+	assert, client, executor := setUp(t)
+	client.RegisterTable(postsSchema())
+
+	_, err := client.GetItem("Posts", HashKey("UserId", 42)).Execute()
+	assert.Equal(`dynago: invalid key for table "Posts": missing range key Date`, err.Error())
+	_, err = client.DeleteItem("Posts", Document{"UserId": 42, "Date": "x", "Other": 1}).Execute()
+	assert.Equal(`dynago: invalid key for table "Posts": unexpected attribute Other in key`, err.Error())
+	_, err = client.UpdateItem("Posts", HashRangeKey("UserId", "42", "Date", "x")).Execute()
+	assert.Equal(`dynago: invalid key for table "Posts": hash key UserId should be of type N, not string`, err.Error())
+	_, err = client.BatchGet().Get("Posts", HashKey("Date", "x")).Execute()
+	assert.IsType(&KeyError{}, err)
+	assert.Equal(0, len(executor.Calls))
+
+	// Unregistered tables aren't checked.
+	_, err = client.GetItem("Other", HashKey("Foo", true)).Execute()
+	assert.NoError(err)
+	_, err = client.GetItem("Posts", HashRangeKey("UserId", Number("42"), "Date", "x")).Execute()
+	assert.NoError(err)
+	assert.Equal(2, len(executor.Calls))
+}
+
+func TestValidateTimeKey(t *testing.T) {
+	assert, client, executor := setUp(t)
+	client.RegisterTable(postsSchema())
+	dated := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := client.GetItem("Posts", HashRangeKey("UserId", 42, "Date", dated)).Execute()
+	assert.NoError(err)
+	_, err = client.UpdateItem("Posts", HashRangeKey("UserId", float32(42), "Date", &dated)).Execute()
+	assert.NoError(err)
+	_, err = client.DeleteItem("Posts", HashRangeKey("UserId", 42, "Date", dated)).Execute()
+	assert.NoError(err)
+	assert.Equal(3, len(executor.Calls))
+
+	_, err = client.GetItem("Posts", HashRangeKey("UserId", dated, "Date", "x")).Execute()
+	assert.Equal(`dynago: invalid key for table "Posts": hash key UserId should be of type N, not time.Time`, err.Error())
+	_, err = client.GetItem("Posts", HashRangeKey("UserId", 42, "Date", dated.In(time.FixedZone("EST", -5*3600)))).Execute()
+	assert.Equal(`dynago: invalid key for table "Posts": range key Date should be a UTC time, not EST`, err.Error())
+	var missing *time.Time
+	_, err = client.GetItem("Posts", HashRangeKey("UserId", 42, "Date", missing)).Execute()
+	assert.Equal(`dynago: invalid key for table "Posts": range key Date should be of type S, not *time.Time`, err.Error())
+}
+
+func TestBatchGetDedupe(t *testing.T) {
+	assert, client, executor := setUp(t)
+	client.RegisterTable(postsSchema())
+	k1 := HashRangeKey("UserId", 42, "Date", "a")
+	k2 := HashRangeKey("UserId", Number("42.0"), "Date", "a")
+	k3 := HashRangeKey("UserId", 42, "Date", "b")
+	executor.BatchGetItemResult = &BatchGetResult{}
+	_, err := client.BatchGet().
+		Get("Posts", k1, k2, k3, k1).
+		Get("Other", HashKey("Id", 1), HashKey("Id", 1)).
+		Execute()
+	assert.NoError(err)
+	assert.Equal([]Document{k1, k3}, executor.BatchGetItemCall.BatchGets["Posts"].Keys)
+	assert.Equal(2, len(executor.BatchGetItemCall.BatchGets["Other"].Keys))
+}
@@ -0,0 +1,112 @@
+package dynago
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tablePost struct {
+	UserId int
+	Date   string
+	Title  string `dynago:",omitempty"`
+}
+
+func tableSetUp(t *testing.T) (*assert.Assertions, *Table[tablePost], *MockExecutor) {
+	assert, client, executor := setUp(t)
+	return assert, NewTable[tablePost](client, "Posts", "UserId", "Date"), executor
+}
+
+func TestTableGetPutDelete(t *testing.T) {
+	assert, posts, executor := tableSetUp(t)
+	ctx := context.Background()
+	key := Key{Hash: 1, Range: "2020-01-01"}
+
+	executor.Expect("GetItem").Key(HashRangeKey("UserId", 1, "Date", "2020-01-01")).
+		Return(&GetItemResult{Item: Document{"UserId": Number("1"), "Date": "2020-01-01", "Title": "Hello"}})
+	executor.Expect("GetItem").Return(&GetItemResult{})
+	post, err := posts.Get(ctx, key)
+	assert.NoError(err)
+	assert.Equal(tablePost{1, "2020-01-01", "Hello"}, post)
+	_, err = posts.Get(ctx, key)
+	assert.Equal(ErrItemNotFound, err)
+
+	assert.NoError(posts.Put(ctx, post))
+	assert.Equal(Document{"UserId": int64(1), "Date": "2020-01-01", "Title": "Hello"}, executor.PutItemCall.Item)
+
+	assert.NoError(posts.Delete(ctx, key))
+	assert.Equal(HashRangeKey("UserId", 1, "Date", "2020-01-01"), executor.DeleteItemCall.Key)
+
+	executor.UpdateItemResult = &UpdateItemResult{Attributes: Document{"UserId": Number("1"), "Date": "2020-01-01", "Title": "Bye"}}
+	post, err = posts.Update(ctx, key, "SET Title = :t", P(":t", "Bye"))
+	assert.NoError(err)
+	assert.Equal("Bye", post.Title)
+	assert.Equal(ReturnAllNew, executor.UpdateItemCall.ReturnValues)
+	assert.NoError(executor.ExpectationsMet())
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.True(errors.Is(posts.Put(canceled, post), context.Canceled))
+}
+
+func TestTableQuery(t *testing.T) {
+	assert, posts, executor := tableSetUp(t)
+	executor.Expect("Query").Return(&QueryResult{
+		Items:            []Document{{"UserId": Number("1"), "Date": "a"}},
+		LastEvaluatedKey: HashRangeKey("UserId", 1, "Date", "a"),
+	})
+	executor.Expect("Query").Return(&QueryResult{Items: []Document{{"UserId": Number("1"), "Date": "b"}}})
+
+	query := posts.Query().KeyConditionExpression("UserId = :id", P(":id", 1))
+	items, err := posts.QueryAll(context.Background(), query)
+	assert.NoError(err)
+	assert.Equal([]tablePost{{UserId: 1, Date: "a"}, {UserId: 1, Date: "b"}}, items)
+	assert.NoError(executor.ExpectationsMet())
+
+	// Stopping the iteration early doesn't fetch more pages.
+	executor.Expect("Query").Return(&QueryResult{
+		Items:            []Document{{"UserId": Number("1"), "Date": "a"}, {"UserId": Number("1"), "Date": "b"}},
+		LastEvaluatedKey: HashRangeKey("UserId", 1, "Date", "b"),
+	})
+	count := 0
+	posts.QueryItems(context.Background(), query)(func(post tablePost, err error) bool {
+		count++
+		return false
+	})
+	assert.Equal(1, count)
+	assert.NoError(executor.ExpectationsMet())
+}
+
+func TestTableBatchGet(t *testing.T) {
+	assert, posts, executor := tableSetUp(t)
+	executor.Expect("BatchGetItem").Return(&BatchGetResult{
+		Responses: map[string][]Document{"Posts": {{"UserId": Number("1"), "Date": "a", "Title": "A"}}},
+		UnprocessedKeys: BatchGetTableMap{"Posts": &BatchGetTableEntry{
+			Keys: []Document{HashRangeKey("UserId", Number("2"), "Date", "b")},
+		}},
+	})
+	executor.Expect("BatchGetItem").Match(func(call *MockExecutorCall) bool {
+		return len(call.BatchGets["Posts"].Keys) == 1
+	}).Return(&BatchGetResult{
+		Responses: map[string][]Document{"Posts": {
+			{"UserId": Number("2"), "Date": "b", "Title": "B"},
+			{"Title": "No key"},
+		}},
+	})
+
+	keys := []Key{{1, "a"}, {2, "b"}, {3, "c"}, {1, "a"}}
+	items, err := posts.BatchGet(context.Background(), keys)
+	assert.NoError(err)
+	assert.Equal(map[Key]tablePost{
+		{1, "a"}: {1, "a", "A"},
+		{2, "b"}: {2, "b", "B"},
+	}, items)
+	assert.Equal(3, len(executor.Calls[0].BatchGets["Posts"].Keys))
+	assert.NoError(executor.ExpectationsMet())
+
+	_, err = posts.BatchGet(context.Background(), []Key{{1, "a"}, {2, []byte("b")}})
+	assert.EqualError(err, "dynago: BatchGet can't use a key of type []uint8 as a map key")
+	assert.Equal(2, len(executor.Calls))
+}
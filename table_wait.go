@@ -0,0 +1,146 @@
+package dynago
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rmfarrell/dynago/schema"
+)
+
+// TableState is a state of a table which can be waited on with WaitForTable.
+type TableState int
+
+// All the table states which can be waited on.
+const (
+	TableActive  TableState = iota // Table and all its global secondary indexes are ACTIVE
+	TableDeleted                   // Table no longer exists
+)
+
+// ErrWaitTimeout is returned by WaitForTable when WaitOptions.Timeout elapses.
+var ErrWaitTimeout = errors.New("dynago: timed out waiting for table state")
+
+/*
+WaitOptions controls how WaitForTable polls.
+
+All fields are optional; the zero value polls with the default backoff until
+the table reaches the desired state or the process is killed.
+*/
+type WaitOptions struct {
+	Context  context.Context // If set, waiting stops when it is done.
+	Timeout  time.Duration   // If non-zero, waiting fails with ErrWaitTimeout after this.
+	MinDelay time.Duration   // Delay before the second poll. Defaults to 500ms.
+	MaxDelay time.Duration   // Cap on the doubling delay between polls. Defaults to 20s.
+
+	// Progress, if set, is called after every DescribeTable poll.
+	Progress func(WaitProgress)
+}
+
+// WaitProgress describes the state of a table while waiting on it.
+type WaitProgress struct {
+	Attempt     int                      // Number of DescribeTable calls made so far
+	Elapsed     time.Duration            // Time since waiting started
+	Table       *schema.TableDescription // nil if the table was not found
+	Backfilling []string                 // Names of global secondary indexes currently backfilling
+}
+
+/*
+WaitForTable polls DescribeTable until the table reaches the given state.
+
+For TableActive, the table status and the status of every global secondary
+index must be ACTIVE; the last description is returned. For TableDeleted,
+waiting finishes when DescribeTable reports ErrorNotFound, and the returned
+description is nil.
+
+opts may be nil to use the defaults.
+*/
+func (c *Client) WaitForTable(table string, state TableState, opts *WaitOptions) (*schema.TableDescription, error) {
+	if opts == nil {
+		opts = &WaitOptions{}
+	}
+	parent := opts.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx := parent
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, opts.Timeout)
+		defer cancel()
+	}
+	delay, maxDelay := opts.MinDelay, opts.MaxDelay
+	if delay <= 0 {
+		delay = 500 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = 20 * time.Second
+	}
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		var desc *schema.TableDescription
+		resp, err := c.DescribeTable(table)
+		if err == nil {
+			desc = &resp.Table
+		} else if !errors.Is(err, ErrorNotFound) || state != TableDeleted {
+			return nil, err
+		}
+		if opts.Progress != nil {
+			opts.Progress(WaitProgress{attempt, time.Since(start), desc, backfillingIndexes(desc)})
+		}
+		if tableInState(desc, state) {
+			return desc, nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if parent.Err() != nil {
+				return desc, parent.Err()
+			}
+			return desc, ErrWaitTimeout
+		case <-timer.C:
+		}
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// WaitUntilActive is a shortcut for WaitForTable(table, TableActive, nil).
+func (c *Client) WaitUntilActive(table string) (*schema.TableDescription, error) {
+	return c.WaitForTable(table, TableActive, nil)
+}
+
+// WaitUntilDeleted is a shortcut for WaitForTable(table, TableDeleted, nil).
+func (c *Client) WaitUntilDeleted(table string) error {
+	_, err := c.WaitForTable(table, TableDeleted, nil)
+	return err
+}
+
+func tableInState(desc *schema.TableDescription, state TableState) bool {
+	if state == TableDeleted {
+		return desc == nil
+	}
+	if desc.TableStatus != schema.StatusActive {
+		return false
+	}
+	for _, index := range desc.GlobalSecondaryIndexes {
+		if index.IndexStatus != schema.StatusActive {
+			return false
+		}
+	}
+	return true
+}
+
+func backfillingIndexes(desc *schema.TableDescription) (names []string) {
+	if desc != nil {
+		for _, index := range desc.GlobalSecondaryIndexes {
+			if index.Backfilling {
+				names = append(names, index.IndexName)
+			}
+		}
+	}
+	return
+}
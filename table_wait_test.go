@@ -0,0 +1,96 @@
+package dynago
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rmfarrell/dynago/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+// describeSequence is a SchemaExecutor which answers DescribeTable from a list.
+type describeSequence struct {
+	SchemaExecutor
+	tables []*schema.TableDescription
+	calls  int
+}
+
+func (d *describeSequence) DescribeTable(req *schema.DescribeRequest) (*schema.DescribeResponse, error) {
+	i := d.calls
+	if i >= len(d.tables) {
+		i = len(d.tables) - 1
+	}
+	d.calls++
+	if d.tables[i] == nil {
+		return nil, &Error{Type: ErrorNotFound}
+	}
+	return &schema.DescribeResponse{Table: *d.tables[i]}, nil
+}
+
+func waitSetUp(t *testing.T, tables ...*schema.TableDescription) (*assert.Assertions, *Client, *describeSequence) {
+	t.Parallel()
+	seq := &describeSequence{tables: tables}
+	return assert.New(t), &Client{executor: &MockExecutor{}, schemaExecutor: seq}, seq
+}
+
+func tableWithIndex(status, indexStatus string, backfilling bool) *schema.TableDescription {
+	index := schema.SecondaryIndexResponse{IndexStatus: indexStatus, Backfilling: backfilling}
+	index.IndexName = "index1"
+	return &schema.TableDescription{
+		TableName:              "table",
+		TableStatus:            status,
+		GlobalSecondaryIndexes: []schema.SecondaryIndexResponse{index},
+	}
+}
+
+func TestWaitForTableActive(t *testing.T) {
+	assert, client, seq := waitSetUp(t,
+		tableWithIndex(schema.StatusCreating, schema.StatusCreating, false),
+		tableWithIndex(schema.StatusActive, schema.StatusCreating, true),
+		tableWithIndex(schema.StatusActive, schema.StatusActive, false),
+	)
+	var progress []WaitProgress
+	desc, err := client.WaitForTable("table", TableActive, &WaitOptions{
+		MinDelay: time.Millisecond,
+		Progress: func(p WaitProgress) { progress = append(progress, p) },
+	})
+	assert.NoError(err)
+	assert.Equal(3, seq.calls)
+	assert.Equal(schema.StatusActive, desc.GlobalSecondaryIndexes[0].IndexStatus)
+	assert.Equal(3, len(progress))
+	assert.Equal(2, progress[1].Attempt)
+	assert.Equal([]string{"index1"}, progress[1].Backfilling)
+	assert.Nil(progress[2].Backfilling)
+}
+
+func TestWaitForTableDeleted(t *testing.T) {
+	assert, client, seq := waitSetUp(t,
+		tableWithIndex(schema.StatusDeleting, schema.StatusDeleting, false),
+		nil,
+	)
+	desc, err := client.WaitForTable("table", TableDeleted, &WaitOptions{MinDelay: time.Millisecond})
+	assert.NoError(err)
+	assert.Nil(desc)
+	assert.Equal(2, seq.calls)
+
+	// A missing table is an error when waiting for it to become active.
+	_, err = client.WaitForTable("table", TableActive, nil)
+	assert.Error(err)
+	assert.Equal(ErrorNotFound, err.(*Error).Type)
+}
+
+func TestWaitForTableTimeout(t *testing.T) {
+	assert, client, _ := waitSetUp(t, tableWithIndex(schema.StatusUpdating, schema.StatusActive, false))
+	_, err := client.WaitForTable("table", TableActive, &WaitOptions{
+		Timeout:  5 * time.Millisecond,
+		MinDelay: time.Millisecond,
+		MaxDelay: 2 * time.Millisecond,
+	})
+	assert.Equal(ErrWaitTimeout, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.WaitForTable("table", TableActive, &WaitOptions{Context: ctx, Timeout: time.Hour})
+	assert.Equal(context.Canceled, err)
+}
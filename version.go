@@ -0,0 +1,273 @@
+package dynago
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+/*
+ErrVersionConflict is returned when a versioned write fails because the item's
+version isn't the expected one, meaning someone else changed it first.
+
+The error also wraps the *Error from DynamoDB, so errors.Is(err,
+ErrorConditionFailed) is true as well.
+*/
+var ErrVersionConflict = errors.New("dynago: version conflict")
+
+// Placeholders used in version checks.
+const (
+	versionName      = "#dynagoVersion"
+	versionValue     = ":dynagoVersion"
+	versionNextValue = ":dynagoNextVersion"
+	versionZeroValue = ":dynagoZero"
+)
+
+// Finds the SET clause of an update expression. SET is a reserved word, so it
+// can't be an attribute name, but placeholders such as :set can contain it.
+var setClause = regexp.MustCompile(`(?i)(?:^|\s)SET\s+`)
+
+/*
+SetVersionAttribute turns on optimistic locking for a table, using a numeric
+attribute which holds the version of each item. An empty attribute turns it off.
+
+With a version attribute:
+
+  - PutItem expects the item in the table to have the version given in the
+    item being put, or for there to be no item if the version is missing or
+    zero, and writes the item with the next version.
+  - UpdateItem increments the version, and expects the version given with
+    ExpectVersion if any.
+  - DeleteItem expects the version given with ExpectVersion if any.
+
+Writes which fail because of the version fail with ErrVersionConflict, and
+can be retried after reading the item again:
+
+	client.SetVersionAttribute("Accounts", "Version")
+	account, _ := client.GetItem("Accounts", key).Execute()
+	account.Item["Balance"] = newBalance
+	_, err := client.PutItem("Accounts", account.Item).Execute()
+	if errors.Is(err, dynago.ErrVersionConflict) {
+		// Someone else updated the account; read it again and retry.
+	}
+
+BatchWriteItem can't make conditional writes, so BatchWrite falls back to
+PutItem for puts to tables with a version attribute, which are checked and
+incremented like any other put. Deletes in a BatchWrite aren't checked.
+*/
+func (c *Client) SetVersionAttribute(table, attribute string) {
+	c.versions.lock.Lock()
+	defer c.versions.lock.Unlock()
+	if c.versions.tables == nil {
+		c.versions.tables = make(map[string]string)
+	}
+	if attribute == "" {
+		delete(c.versions.tables, table)
+	} else {
+		c.versions.tables[table] = attribute
+	}
+}
+
+type versionAttributes struct {
+	lock   sync.RWMutex
+	tables map[string]string
+}
+
+// The version attribute for a request, which overrides the client's setting for the table.
+func (c *Client) versionAttribute(table, override string) string {
+	if override != "" {
+		return override
+	}
+	c.versions.lock.RLock()
+	defer c.versions.lock.RUnlock()
+	return c.versions.tables[table]
+}
+
+// versionOptions are the version settings given on a request.
+type versionOptions struct {
+	attribute string
+	expected  *int64
+}
+
+// versionCheck turns failed version conditions into version conflicts.
+type versionCheck struct {
+	table     string
+	attribute string
+	expected  int64
+
+	// If the request had its own condition, the item is returned on failure
+	// to tell which condition failed.
+	userCondition bool
+}
+
+func (v *versionCheck) check(err error) error {
+	var e *Error
+	if v == nil || !errors.As(err, &e) || e.Type != ErrorConditionFailed {
+		return err
+	}
+	if v.userCondition {
+		if actual, parseErr := itemVersion(e.Item, v.attribute); parseErr == nil && actual == v.expected {
+			return err // The version was fine, so the other condition failed.
+		}
+	}
+	return fmt.Errorf("%w on table %q, expected version %d: %w", ErrVersionConflict, v.table, v.expected, err)
+}
+
+// The condition and params checking for a version.
+func versionCondition(existing string, expected int64) (string, []Params) {
+	condition := "attribute_not_exists(" + versionName + ")"
+	var params []Params
+	if expected != 0 {
+		condition = versionName + " = " + versionValue
+		params = append(params, P(versionValue, expected))
+	}
+	if existing != "" {
+		condition = "(" + existing + ") AND " + condition
+	}
+	return condition, params
+}
+
+// Add an action to the SET clause of an update expression, adding the clause if needed.
+func addSetAction(expression, action string) string {
+	if loc := setClause.FindStringIndex(expression); loc != nil {
+		return expression[:loc[1]] + action + ", " + expression[loc[1]:]
+	} else if expression == "" {
+		return "SET " + action
+	}
+	return expression + " SET " + action
+}
+
+// The version of an item, which is zero if the item or the attribute is missing.
+func itemVersion(item Document, attribute string) (int64, error) {
+	version, err := itemInt(item, attribute)
+	if err != nil {
+		return 0, fmt.Errorf("dynago: version attribute %s should be an integer, not %v", attribute, item[attribute])
+	}
+	return version, nil
+}
+
+func (p PutItem) withVersion(attribute string) (*PutItem, *versionCheck, error) {
+	expected, err := itemVersion(p.req.Item, attribute)
+	if err != nil {
+		return nil, nil, err
+	}
+	item := make(Document, len(p.req.Item)+1)
+	for k, v := range p.req.Item {
+		item[k] = v
+	}
+	item[attribute] = expected + 1
+	p.req.Item = item
+
+	check := &versionCheck{table: p.req.TableName, attribute: attribute, expected: expected, userCondition: p.req.ConditionExpression != ""}
+	if check.userCondition {
+		p.req.ReturnValuesOnConditionCheckFailure = ReturnAllOld
+	}
+	condition, params := versionCondition(p.req.ConditionExpression, expected)
+	p.req.ConditionExpression = condition
+	p.req.paramsHelper(append(params, P(versionName, attribute)))
+	return &p, check, nil
+}
+
+func (u UpdateItem) withVersion(attribute string) (*UpdateItem, *versionCheck) {
+	if u.version.expected == nil {
+		u.req.UpdateExpression = addSetAction(u.req.UpdateExpression,
+			versionName+" = if_not_exists("+versionName+", "+versionZeroValue+") + "+versionNextValue)
+		u.req.paramsHelper([]Params{P(versionName, attribute), P(versionZeroValue, 0), P(versionNextValue, 1)})
+		return &u, nil
+	}
+
+	expected := *u.version.expected
+	check := &versionCheck{table: u.req.TableName, attribute: attribute, expected: expected, userCondition: u.req.ConditionExpression != ""}
+	if check.userCondition {
+		u.req.ReturnValuesOnConditionCheckFailure = ReturnAllOld
+	}
+	condition, params := versionCondition(u.req.ConditionExpression, expected)
+	u.req.ConditionExpression = condition
+	u.req.UpdateExpression = addSetAction(u.req.UpdateExpression, versionName+" = "+versionNextValue)
+	u.req.paramsHelper(append(params, P(versionName, attribute), P(versionNextValue, expected+1)))
+	return &u, check
+}
+
+func (d DeleteItem) withVersion(attribute string) (*DeleteItem, *versionCheck) {
+	if d.version.expected == nil {
+		return &d, nil
+	}
+	expected := *d.version.expected
+	check := &versionCheck{table: d.req.TableName, attribute: attribute, expected: expected, userCondition: d.req.ConditionExpression != ""}
+	if check.userCondition {
+		d.req.ReturnValuesOnConditionCheckFailure = ReturnAllOld
+	}
+	condition, params := versionCondition(d.req.ConditionExpression, expected)
+	d.req.ConditionExpression = condition
+	d.req.paramsHelper(append(params, P(versionName, attribute)))
+	return &d, check
+}
+
+/*
+putVersioned makes the puts of a batch to tables with a version attribute one
+at a time, in the order they were added, and gives the rest of the batch. The
+result has the capacity and metrics of the puts, if they were asked for.
+*/
+func (b *BatchWrite) putVersioned() (*BatchWrite, *BatchWriteResult, error) {
+	var versioned, rest []*batchAction
+	for put := b.puts; put != nil; put = put.next {
+		if b.client.versionAttribute(put.table, "") != "" {
+			versioned = append(versioned, put)
+		} else {
+			rest = append(rest, put)
+		}
+	}
+	if len(versioned) == 0 {
+		return b, nil, nil
+	}
+	result := &BatchWriteResult{}
+	for i := len(versioned) - 1; i >= 0; i-- {
+		put := versioned[i]
+		putResult, err := b.client.PutItem(put.table, put.item).
+			ReturnConsumedCapacity(b.capacityDetail).
+			ReturnItemCollectionMetrics(b.collectionMetricsDetail).
+			Execute()
+		if err != nil {
+			return nil, result, err
+		}
+		result.addPut(put.table, putResult)
+	}
+	remaining := *b
+	remaining.puts = nil
+	for i := len(rest) - 1; i >= 0; i-- {
+		remaining.puts = &batchAction{remaining.puts, rest[i].table, rest[i].item}
+	}
+	return &remaining, result, nil
+}
+
+// Add the unprocessed items, capacity and metrics of a batch to the result.
+func (r *BatchWriteResult) add(batch *BatchWriteResult) {
+	if batch == nil {
+		return
+	}
+	r.UnprocessedItems = batch.UnprocessedItems
+	r.ConsumedCapacity = append(r.ConsumedCapacity, batch.ConsumedCapacity...)
+	for table, metrics := range batch.ItemCollectionMetrics {
+		if r.ItemCollectionMetrics == nil {
+			r.ItemCollectionMetrics = BatchItemCollectionMetrics{}
+		}
+		r.ItemCollectionMetrics[table] = append(r.ItemCollectionMetrics[table], metrics...)
+	}
+}
+
+// Add the capacity and metrics of a put to table to the result.
+func (r *BatchWriteResult) addPut(table string, put *PutItemResult) {
+	if put == nil {
+		return
+	}
+	if put.ConsumedCapacity != nil {
+		r.ConsumedCapacity = append(r.ConsumedCapacity, *put.ConsumedCapacity)
+	}
+	if put.ItemCollectionMetrics != nil {
+		if r.ItemCollectionMetrics == nil {
+			r.ItemCollectionMetrics = BatchItemCollectionMetrics{}
+		}
+		r.ItemCollectionMetrics[table] = append(r.ItemCollectionMetrics[table], *put.ItemCollectionMetrics)
+	}
+}
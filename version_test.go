@@ -0,0 +1,168 @@
+package dynago
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func conditionFailed(item Document) error {
+	e := faultError(ErrorConditionFailed)
+	e.Item = item
+	return e
+}
+
+func TestVersionPutItem(t *testing.T) {
+	assert, client, executor := setUp(t)
+	client.SetVersionAttribute("Accounts", "Version")
+
+	item := Document{"Id": 1, "Balance": 10}
+	_, err := client.PutItem("Accounts", item).Execute()
+	assert.NoError(err)
+	call := executor.PutItemCall
+	assert.Equal("attribute_not_exists(#dynagoVersion)", call.ConditionExpression)
+	assert.Equal(map[string]string{"#dynagoVersion": "Version"}, call.ExpressionAttributeNames)
+	assert.Equal(Document{"Id": 1, "Balance": 10, "Version": int64(1)}, call.Item)
+	assert.Equal(Document{"Id": 1, "Balance": 10}, item)
+
+	item = Document{"Id": 1, "Balance": 20, "Version": Number("3")}
+	executor.PutItemError = conditionFailed(nil)
+	_, err = client.PutItem("Accounts", item).Execute()
+	assert.True(errors.Is(err, ErrVersionConflict))
+	assert.True(errors.Is(err, ErrorConditionFailed))
+	assert.Equal(`dynago: version conflict on table "Accounts", expected version 3: dynago.Error(ErrorConditionFailed): ConditionalCheckFailedException: injected fault`, err.Error())
+	call = executor.PutItemCall
+	assert.Equal("#dynagoVersion = :dynagoVersion", call.ConditionExpression)
+	assert.Equal(Document{":dynagoVersion": int64(3)}, call.ExpressionAttributeValues)
+	assert.Equal(int64(4), call.Item["Version"])
+
+	// Other tables and other conditions are left alone.
+	executor.PutItemError = conditionFailed(nil)
+	_, err = client.PutItem("Other", item).ConditionExpression("Balance > :b", P(":b", 0)).Execute()
+	assert.False(errors.Is(err, ErrVersionConflict))
+	assert.Equal("Balance > :b", executor.PutItemCall.ConditionExpression)
+
+	_, err = client.PutItem("Accounts", Document{"Id": 1, "Version": "three"}).Execute()
+	assert.EqualError(err, "dynago: version attribute Version should be an integer, not three")
+}
+
+func TestVersionBatchWrite(t *testing.T) {
+	assert, client, executor := setUp(t)
+	client.SetVersionAttribute("Accounts", "Version")
+	executor.PutItemResult = &PutItemResult{ConsumedCapacity: &ConsumedCapacity{TableName: "Accounts", CapacityUnits: 1}}
+	executor.BatchWriteItemResult = &BatchWriteResult{ConsumedCapacity: BatchConsumedCapacity{{TableName: "Other", CapacityUnits: 1}}}
+
+	result, err := client.BatchWrite().
+		Put("Accounts", Document{"Id": 1}, Document{"Id": 2, "Version": 3}).
+		Put("Other", Document{"Id": 3}).
+		Delete("Accounts", HashKey("Id", 4)).
+		ReturnConsumedCapacity(CapacityTotal).
+		Execute()
+	assert.NoError(err)
+	assert.Equal(3, len(executor.Calls))
+	assert.Equal(Document{"Id": 1, "Version": int64(1)}, executor.Calls[0].Item)
+	assert.Equal("attribute_not_exists(#dynagoVersion)", executor.Calls[0].ConditionExpression)
+	assert.Equal(Document{"Id": 2, "Version": int64(4)}, executor.Calls[1].Item)
+	assert.Equal("#dynagoVersion = :dynagoVersion", executor.Calls[1].ConditionExpression)
+	assert.Equal(BatchWriteTableMap{
+		"Other":    {{PutRequest: &batchPut{Document{"Id": 3}}}},
+		"Accounts": {{DeleteRequest: &batchDelete{HashKey("Id", 4)}}},
+	}, executor.Calls[2].BatchWrites)
+	assert.Equal(3, len(result.ConsumedCapacity))
+
+	// A version conflict stops the batch.
+	executor.PutItemError = conditionFailed(nil)
+	_, err = client.BatchWrite().Put("Accounts", Document{"Id": 1}).Put("Other", Document{"Id": 3}).Execute()
+	assert.True(errors.Is(err, ErrVersionConflict))
+	assert.Equal(4, len(executor.Calls))
+
+	// Without other writes, there's no batch.
+	executor.PutItemError = nil
+	result, err = client.BatchWrite().Put("Accounts", Document{"Id": 1}).Execute()
+	assert.NoError(err)
+	assert.NotNil(result)
+	assert.Equal("PutItem", executor.Calls[4].Method)
+	assert.Equal(5, len(executor.Calls))
+}
+
+func TestVersionUserCondition(t *testing.T) {
+	assert, client, executor := setUp(t)
+	put := client.PutItem("Accounts", Document{"Id": 1, "Version": 2}).
+		VersionAttribute("Version").
+		ConditionExpression("Balance > :b", P(":b", 0))
+
+	// The item has the expected version, so the other condition failed.
+	executor.PutItemError = conditionFailed(Document{"Id": Number("1"), "Version": Number("2")})
+	_, err := put.Execute()
+	assert.True(errors.Is(err, ErrorConditionFailed))
+	assert.False(errors.Is(err, ErrVersionConflict))
+	assert.Equal("(Balance > :b) AND #dynagoVersion = :dynagoVersion", executor.PutItemCall.ConditionExpression)
+	assert.Equal(Document{":b": 0, ":dynagoVersion": int64(2)}, executor.PutItemCall.ExpressionAttributeValues)
+
+	executor.PutItemError = conditionFailed(Document{"Id": Number("1"), "Version": Number("5")})
+	_, err = put.Execute()
+	assert.True(errors.Is(err, ErrVersionConflict))
+}
+
+func TestVersionUpdateItem(t *testing.T) {
+	assert, client, executor := setUp(t)
+	client.SetVersionAttribute("Accounts", "Version")
+	key := HashKey("Id", 1)
+
+	client.UpdateItem("Accounts", key).UpdateExpression("SET Balance = :b", P(":b", 5)).Execute()
+	call := executor.UpdateItemCall
+	assert.Equal("SET #dynagoVersion = if_not_exists(#dynagoVersion, :dynagoZero) + :dynagoNextVersion, Balance = :b", call.UpdateExpression)
+	assert.Equal("", call.ConditionExpression)
+
+	executor.UpdateItemError = conditionFailed(nil)
+	_, err := client.UpdateItem("Accounts", key).UpdateExpression("ADD Balance :b", P(":b", 5)).ExpectVersion(7).Execute()
+	assert.True(errors.Is(err, ErrVersionConflict))
+	call = executor.UpdateItemCall
+	assert.Equal("ADD Balance :b SET #dynagoVersion = :dynagoNextVersion", call.UpdateExpression)
+	assert.Equal("#dynagoVersion = :dynagoVersion", call.ConditionExpression)
+	assert.Equal(Document{":b": 5, ":dynagoVersion": int64(7), ":dynagoNextVersion": int64(8)}, call.ExpressionAttributeValues)
+
+	client.SetVersionAttribute("Accounts", "")
+	client.UpdateItem("Accounts", key).UpdateExpression("SET Balance = :b", P(":b", 5)).ExpectVersion(7).Execute()
+	assert.Equal("SET Balance = :b", executor.UpdateItemCall.UpdateExpression)
+}
+
+func TestAddSetAction(t *testing.T) {
+	assert, _, _ := setUp(t)
+	assert.Equal("SET A = :a", addSetAction("", "A = :a"))
+	assert.Equal("REMOVE B set A = :a, C = :c", addSetAction("REMOVE B set C = :c", "A = :a"))
+	assert.Equal("ADD #tags :set REMOVE #old SET A = :a", addSetAction("ADD #tags :set REMOVE #old", "A = :a"))
+	assert.Equal("ADD #set :s SET A = :a", addSetAction("ADD #set :s", "A = :a"))
+}
+
+func TestVersionDeleteItem(t *testing.T) {
+	assert, client, executor := setUp(t)
+	client.SetVersionAttribute("Accounts", "Version")
+	key := HashKey("Id", 1)
+
+	client.DeleteItem("Accounts", key).Execute()
+	assert.Equal("", executor.DeleteItemCall.ConditionExpression)
+
+	executor.DeleteItemError = conditionFailed(nil)
+	_, err := client.DeleteItem("Accounts", key).ExpectVersion(2).Execute()
+	assert.True(errors.Is(err, ErrVersionConflict))
+	assert.Equal("#dynagoVersion = :dynagoVersion", executor.DeleteItemCall.ConditionExpression)
+}
+
+func TestVersionTable(t *testing.T) {
+	type account struct {
+		Id      int
+		Balance int
+		Version int64 `dynago:",version"`
+	}
+	assert, client, executor := setUp(t)
+	accounts := NewTable[account](client, "Accounts", "Id", "")
+
+	assert.NoError(accounts.Put(context.Background(), account{Id: 1, Balance: 5, Version: 3}))
+	assert.Equal(int64(4), executor.PutItemCall.Item["Version"])
+	assert.Equal("#dynagoVersion = :dynagoVersion", executor.PutItemCall.ConditionExpression)
+
+	executor.PutItemError = conditionFailed(nil)
+	err := accounts.Put(context.Background(), account{Id: 1, Balance: 5, Version: 3})
+	assert.True(errors.Is(err, ErrVersionConflict))
+}
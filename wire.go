@@ -19,6 +19,8 @@ func wireEncode(value interface{}) interface{} {
 		return &wireBool{v}
 	case float64:
 		return &wireNumber{strconv.FormatFloat(v, 'g', -1, 64)}
+	case float32:
+		return &wireNumber{strconv.FormatFloat(float64(v), 'g', -1, 32)}
 	case Number:
 		return &wireNumber{string(v)}
 	case Document:
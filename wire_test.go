@@ -41,6 +41,7 @@ func TestWireEncodeBasic(t *testing.T) {
 	check(wireNumber{"7"}, int(7), `{"N":"7"}`)
 	check(wireNumber{"-45"}, int64(-45), `{"N":"-45"}`)
 	check(wireNumber{"4.55"}, float64(4.55), `{"N":"4.55"}`)
+	check(wireNumber{"4.55"}, float32(4.55), `{"N":"4.55"}`)
 	check(wireNumberSet{[]string{"4", "5"}}, NumberSet{"4", "5"}, `{"NS":["4","5"]}`)
 
 	check(wireNumber{"4500"}, uint(4500), `{"N":"4500"}`)